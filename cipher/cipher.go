@@ -4,7 +4,6 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"encoding/base64"
-	"errors"
 )
 
 func Base64Encode(data string) string {
@@ -19,6 +18,11 @@ func Base64Decode(data string) (string, error) {
 	return string(decodedbytes), nil
 }
 
+// Encrypt encrypts text with AES-256 in CFB mode and returns IV||ciphertext.
+//
+// Deprecated: Encrypt is kept only so that previously stored ciphertexts can still be
+// produced and migrated. It always uses an all-zero IV and provides no integrity
+// protection. New code should use Seal.
 func Encrypt(key32 [32]byte, text []byte) ([]byte, error) {
 	key := key32[:]
 	block, err := aes.NewCipher(key)
@@ -33,6 +37,10 @@ func Encrypt(key32 [32]byte, text []byte) ([]byte, error) {
 	return ciphertext, nil
 }
 
+// Decrypt reverses Encrypt.
+//
+// Deprecated: Decrypt is kept only to read ciphertexts produced by Encrypt while they
+// are migrated. New code should use Open.
 func Decrypt(key32 [32]byte, text []byte) ([]byte, error) {
 	key := key32[:]
 	block, err := aes.NewCipher(key)
@@ -40,7 +48,7 @@ func Decrypt(key32 [32]byte, text []byte) ([]byte, error) {
 		return nil, err
 	}
 	if len(text) < aes.BlockSize {
-		return nil, errors.New("ciphertext too short")
+		return nil, ErrCiphertextTooShort
 	}
 	iv := text[:aes.BlockSize]
 	text = text[aes.BlockSize:]
@@ -0,0 +1,85 @@
+package cipher
+
+import (
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// Labels used to derive independent sub keys for deterministic encryption.
+const (
+	deterministicEncInfo = "gowraps deterministic enc v1"
+	deterministicMacInfo = "gowraps deterministic mac v1"
+)
+
+// SealDeterministic encrypts and authenticates plaintext so that the same key,
+// plaintext and additionalData always give the same output. This allows equality
+// lookups on encrypted values, e.g. WHERE email = ?, at the price of revealing which
+// values are equal. Prefer Seal whenever lookups are not needed.
+//
+// The nonce is a synthetic IV: an HMAC-SHA256 of additionalData and plaintext under a
+// key derived from key32, so it only repeats when the whole input repeats. The returned
+// slice is nonce||ciphertext||tag, just like Seal.
+func SealDeterministic(key32 [32]byte, plaintext, additionalData []byte) ([]byte, error) {
+	encKey, macKey, err := deterministicKeys(key32)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(encKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := syntheticIV(macKey, plaintext, additionalData)[:aead.NonceSize()]
+	out := make([]byte, 0, len(nonce)+len(plaintext)+aead.Overhead())
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, plaintext, additionalData), nil
+}
+
+// OpenDeterministic authenticates and decrypts a ciphertext produced by SealDeterministic.
+func OpenDeterministic(key32 [32]byte, ciphertext, additionalData []byte) ([]byte, error) {
+	encKey, macKey, err := deterministicKeys(key32)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(encKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrCiphertextTooShort
+	}
+	nonce := ciphertext[:aead.NonceSize()]
+	plaintext, err := aead.Open(nil, nonce, ciphertext[aead.NonceSize():], additionalData)
+	if err != nil {
+		return nil, ErrAuthFailed
+	}
+	if !hmac.Equal(syntheticIV(macKey, plaintext, additionalData)[:aead.NonceSize()], nonce) {
+		return nil, ErrAuthFailed
+	}
+	return plaintext, nil
+}
+
+// deterministicKeys derives the encryption and IV keys from key32.
+func deterministicKeys(key32 [32]byte) (encKey, macKey [32]byte, err error) {
+	enc, err := hkdf.Key(sha256.New, key32[:], nil, deterministicEncInfo, 32)
+	if err != nil {
+		return
+	}
+	mac, err := hkdf.Key(sha256.New, key32[:], nil, deterministicMacInfo, 32)
+	if err != nil {
+		return
+	}
+	copy(encKey[:], enc)
+	copy(macKey[:], mac)
+	return
+}
+
+// syntheticIV returns HMAC-SHA256(macKey, len(additionalData) || additionalData || plaintext).
+func syntheticIV(macKey [32]byte, plaintext, additionalData []byte) []byte {
+	mac := hmac.New(sha256.New, macKey[:])
+	mac.Write(binary.BigEndian.AppendUint64(nil, uint64(len(additionalData))))
+	mac.Write(additionalData)
+	mac.Write(plaintext)
+	return mac.Sum(nil)
+}
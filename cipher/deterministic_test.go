@@ -0,0 +1,80 @@
+package cipher
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSealDeterministic(t *testing.T) {
+	key := createTestKey()
+
+	first, err := SealDeterministic(key, []byte("jane@example.com"), []byte("email"))
+	if err != nil {
+		t.Fatalf("SealDeterministic() unexpected error = %v", err)
+	}
+	second, _ := SealDeterministic(key, []byte("jane@example.com"), []byte("email"))
+	if !bytes.Equal(first, second) {
+		t.Errorf("SealDeterministic() is not deterministic")
+	}
+
+	tests := []struct {
+		name string
+		key  [32]byte
+		text string
+		ad   string
+	}{
+		{name: "Different plaintext", key: key, text: "john@example.com", ad: "email"},
+		{name: "Different associated data", key: key, text: "jane@example.com", ad: "backup_email"},
+		{name: "Different key", key: createRandomKey(), text: "jane@example.com", ad: "email"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other, err := SealDeterministic(tt.key, []byte(tt.text), []byte(tt.ad))
+			if err != nil {
+				t.Fatalf("SealDeterministic() unexpected error = %v", err)
+			}
+			if bytes.Equal(first, other) {
+				t.Errorf("SealDeterministic() produced the same output for different input")
+			}
+		})
+	}
+
+	opened, err := OpenDeterministic(key, first, []byte("email"))
+	if err != nil {
+		t.Fatalf("OpenDeterministic() unexpected error = %v", err)
+	}
+	if string(opened) != "jane@example.com" {
+		t.Errorf("OpenDeterministic() = %s, want jane@example.com", opened)
+	}
+}
+
+func TestOpenDeterministicErrors(t *testing.T) {
+	key := createTestKey()
+	sealed, _ := SealDeterministic(key, []byte("123-45-6789"), []byte("ssn"))
+	tampered := append([]byte(nil), sealed...)
+	tampered[len(tampered)-1] ^= 0x01
+
+	tests := []struct {
+		name    string
+		key     [32]byte
+		data    []byte
+		ad      string
+		wantErr error
+	}{
+		{name: "Tampered", key: key, data: tampered, ad: "ssn", wantErr: ErrAuthFailed},
+		{name: "Wrong column", key: key, data: sealed, ad: "email", wantErr: ErrAuthFailed},
+		{name: "Wrong key", key: createRandomKey(), data: sealed, ad: "ssn", wantErr: ErrAuthFailed},
+		{name: "Too short", key: key, data: sealed[:10], ad: "ssn", wantErr: ErrCiphertextTooShort},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := OpenDeterministic(tt.key, tt.data, []byte(tt.ad))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("OpenDeterministic() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package cipher
+
+import (
+	"bytes"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+)
+
+// Encoding selects a text encoding for binary data.
+type Encoding int
+
+// Supported encodings. The zero value is Base64Std, the encoding used by Base64Encode.
+const (
+	Base64Std    Encoding = iota // standard alphabet, padded (RFC 4648)
+	Base64URL                    // URL safe alphabet, padded
+	Base64RawStd                 // standard alphabet, without padding
+	Base64RawURL                 // URL safe alphabet, without padding
+	Base64MIME                   // standard alphabet, padded, lines wrapped at 76 characters with CRLF (RFC 2045)
+	Hex                          // lower case hexadecimal
+	Base32Std                    // standard alphabet, padded
+	Base32RawStd                 // standard alphabet, without padding
+	Base32Hex                    // extended hex alphabet, padded
+)
+
+// mimeLineLen is the maximum length of an encoded line in MIME bodies.
+const mimeLineLen = 76
+
+// ErrUnknownEncoding is returned when an Encoding value is not one of the constants above.
+var ErrUnknownEncoding = errors.New("unknown encoding")
+
+// ErrMixedAlphabet is returned by the lenient decoders when the input mixes characters
+// of the standard and URL safe base64 alphabets.
+var ErrMixedAlphabet = errors.New("input mixes base64 alphabets")
+
+// base64Encoding returns the stdlib encoding backing a base64 variant.
+func (e Encoding) base64Encoding() *base64.Encoding {
+	switch e {
+	case Base64Std, Base64MIME:
+		return base64.StdEncoding
+	case Base64URL:
+		return base64.URLEncoding
+	case Base64RawStd:
+		return base64.RawStdEncoding
+	case Base64RawURL:
+		return base64.RawURLEncoding
+	}
+	return nil
+}
+
+// base32Encoding returns the stdlib encoding backing a base32 variant.
+func (e Encoding) base32Encoding() *base32.Encoding {
+	switch e {
+	case Base32Std:
+		return base32.StdEncoding
+	case Base32RawStd:
+		return base32.StdEncoding.WithPadding(base32.NoPadding)
+	case Base32Hex:
+		return base32.HexEncoding
+	}
+	return nil
+}
+
+// EncodeToString returns the encoding of src.
+func (e Encoding) EncodeToString(src []byte) string {
+	return string(e.Encode(src))
+}
+
+// Encode returns the encoding of src. It panics for an unknown Encoding value.
+func (e Encoding) Encode(src []byte) []byte {
+	var buf bytes.Buffer
+	w := e.NewEncoder(&buf)
+	w.Write(src)
+	w.Close()
+	return buf.Bytes()
+}
+
+// DecodeString returns the bytes represented by s.
+func (e Encoding) DecodeString(s string) ([]byte, error) {
+	return e.Decode([]byte(s))
+}
+
+// Decode returns the bytes represented by src.
+func (e Encoding) Decode(src []byte) ([]byte, error) {
+	if enc := e.base64Encoding(); enc != nil {
+		// The stdlib decoder already skips the CR and LF of MIME bodies.
+		dst := make([]byte, enc.DecodedLen(len(src)))
+		n, err := enc.Decode(dst, src)
+		return dst[:n], err
+	}
+	if enc := e.base32Encoding(); enc != nil {
+		dst := make([]byte, enc.DecodedLen(len(src)))
+		n, err := enc.Decode(dst, src)
+		return dst[:n], err
+	}
+	if e == Hex {
+		dst := make([]byte, hex.DecodedLen(len(src)))
+		n, err := hex.Decode(dst, src)
+		return dst[:n], err
+	}
+	return nil, ErrUnknownEncoding
+}
+
+// NewEncoder returns a stream encoder writing to w. Close has to be called to flush
+// any partially written block; it does not close w. It panics for an unknown Encoding value.
+func (e Encoding) NewEncoder(w io.Writer) io.WriteCloser {
+	if e == Base64MIME {
+		lw := &lineWrapper{w: w, max: mimeLineLen}
+		return base64.NewEncoder(base64.StdEncoding, lw)
+	}
+	if enc := e.base64Encoding(); enc != nil {
+		return base64.NewEncoder(enc, w)
+	}
+	if enc := e.base32Encoding(); enc != nil {
+		return base32.NewEncoder(enc, w)
+	}
+	if e == Hex {
+		return nopCloser{hex.NewEncoder(w)}
+	}
+	panic(ErrUnknownEncoding)
+}
+
+// NewDecoder returns a stream decoder reading from r.
+func (e Encoding) NewDecoder(r io.Reader) io.Reader {
+	if enc := e.base64Encoding(); enc != nil {
+		return base64.NewDecoder(enc, r)
+	}
+	if enc := e.base32Encoding(); enc != nil {
+		return base32.NewDecoder(enc, r)
+	}
+	if e == Hex {
+		return hex.NewDecoder(r)
+	}
+	return errReader{ErrUnknownEncoding}
+}
+
+// DecodeLenient decodes s in the same family as e while tolerating the differences
+// between upstream systems:
+//
+//   - base64: either alphabet, with or without padding, with embedded whitespace or line breaks
+//   - base32: with or without padding, either case, with embedded whitespace
+//   - hex: either case, with embedded whitespace and an optional 0x prefix
+func (e Encoding) DecodeLenient(s string) ([]byte, error) {
+	switch {
+	case e.base64Encoding() != nil:
+		return Base64DecodeLenient(s)
+	case e.base32Encoding() != nil:
+		s = strings.TrimRight(strings.ToUpper(stripSpace(s)), "=")
+		if e == Base32Hex {
+			return base32.HexEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+		}
+		return base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+	case e == Hex:
+		s = stripSpace(s)
+		if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+			s = s[2:]
+		}
+		return hex.DecodeString(s)
+	}
+	return nil, ErrUnknownEncoding
+}
+
+// Base64DecodeLenient decodes any of the base64 variants: standard or URL safe
+// alphabet, padded or not, optionally wrapped into lines.
+func Base64DecodeLenient(s string) ([]byte, error) {
+	s = strings.TrimRight(stripSpace(s), "=")
+	url := strings.ContainsAny(s, "-_")
+	if url && strings.ContainsAny(s, "+/") {
+		return nil, ErrMixedAlphabet
+	}
+	if url {
+		return base64.RawURLEncoding.DecodeString(s)
+	}
+	return base64.RawStdEncoding.DecodeString(s)
+}
+
+// Base64URLEncode encodes data with the URL safe alphabet and padding.
+func Base64URLEncode(data string) string {
+	return base64.URLEncoding.EncodeToString([]byte(data))
+}
+
+// Base64URLDecode decodes data encoded with Base64URLEncode.
+func Base64URLDecode(data string) (string, error) {
+	decodedbytes, err := base64.URLEncoding.DecodeString(data)
+	if err != nil {
+		return "", err
+	}
+	return string(decodedbytes), nil
+}
+
+// HexEncode encodes data as lower case hexadecimal.
+func HexEncode(data string) string {
+	return hex.EncodeToString([]byte(data))
+}
+
+// HexDecode decodes hexadecimal data of either case.
+func HexDecode(data string) (string, error) {
+	decodedbytes, err := hex.DecodeString(data)
+	if err != nil {
+		return "", err
+	}
+	return string(decodedbytes), nil
+}
+
+// Base32Encode encodes data with the standard base32 alphabet and padding.
+func Base32Encode(data string) string {
+	return base32.StdEncoding.EncodeToString([]byte(data))
+}
+
+// Base32Decode decodes data encoded with Base32Encode.
+func Base32Decode(data string) (string, error) {
+	decodedbytes, err := base32.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", err
+	}
+	return string(decodedbytes), nil
+}
+
+// stripSpace removes all ASCII whitespace from s.
+func stripSpace(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\r', '\n', '\v', '\f':
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// lineWrapper inserts CRLF after every max bytes written through it.
+type lineWrapper struct {
+	w   io.Writer
+	max int
+	col int
+}
+
+func (l *lineWrapper) Write(p []byte) (int, error) {
+	var n int
+	for len(p) > 0 {
+		if l.col == l.max {
+			if _, err := l.w.Write([]byte("\r\n")); err != nil {
+				return n, err
+			}
+			l.col = 0
+		}
+		k := min(l.max-l.col, len(p))
+		written, err := l.w.Write(p[:k])
+		n += written
+		l.col += written
+		if err != nil {
+			return n, err
+		}
+		p = p[k:]
+	}
+	return n, nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
@@ -0,0 +1,188 @@
+package cipher
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestEncodingRoundTrip(t *testing.T) {
+	data := []byte{0xfb, 0xff, 0xbf, 'H', 'e', 'l', 'l', 'o'}
+
+	tests := []struct {
+		name     string
+		enc      Encoding
+		expected string
+	}{
+		{name: "Base64Std", enc: Base64Std, expected: "+/+/SGVsbG8="},
+		{name: "Base64URL", enc: Base64URL, expected: "-_-_SGVsbG8="},
+		{name: "Base64RawStd", enc: Base64RawStd, expected: "+/+/SGVsbG8"},
+		{name: "Base64RawURL", enc: Base64RawURL, expected: "-_-_SGVsbG8"},
+		{name: "Base64MIME", enc: Base64MIME, expected: "+/+/SGVsbG8="},
+		{name: "Hex", enc: Hex, expected: "fbffbf48656c6c6f"},
+		{name: "Base32Std", enc: Base32Std, expected: "7P736SDFNRWG6==="},
+		{name: "Base32RawStd", enc: Base32RawStd, expected: "7P736SDFNRWG6"},
+		{name: "Base32Hex", enc: Base32Hex, expected: "VFVRUI35DHM6U==="},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := tt.enc.EncodeToString(data)
+			if encoded != tt.expected {
+				t.Errorf("EncodeToString() = %v, want %v", encoded, tt.expected)
+			}
+			decoded, err := tt.enc.DecodeString(encoded)
+			if err != nil {
+				t.Fatalf("DecodeString() unexpected error = %v", err)
+			}
+			if !bytes.Equal(decoded, data) {
+				t.Errorf("DecodeString() = %v, want %v", decoded, data)
+			}
+
+			// Streaming forms produce the same output.
+			var buf bytes.Buffer
+			w := tt.enc.NewEncoder(&buf)
+			for _, b := range data {
+				w.Write([]byte{b})
+			}
+			w.Close()
+			if buf.String() != tt.expected {
+				t.Errorf("NewEncoder() wrote %v, want %v", buf.String(), tt.expected)
+			}
+			streamed, err := io.ReadAll(tt.enc.NewDecoder(&buf))
+			if err != nil || !bytes.Equal(streamed, data) {
+				t.Errorf("NewDecoder() = %v, %v, want %v", streamed, err, data)
+			}
+		})
+	}
+}
+
+func TestBase64MIMEWrapsLines(t *testing.T) {
+	data := bytes.Repeat([]byte("A"), 200)
+	encoded := Base64MIME.EncodeToString(data)
+
+	lines := strings.Split(encoded, "\r\n")
+	if len(lines) != 4 {
+		t.Fatalf("EncodeToString() produced %d lines, want 4", len(lines))
+	}
+	for i, line := range lines[:len(lines)-1] {
+		if len(line) != mimeLineLen {
+			t.Errorf("line %d has %d characters, want %d", i, len(line), mimeLineLen)
+		}
+	}
+	if strings.HasSuffix(encoded, "\r\n") {
+		t.Errorf("EncodeToString() ends with a line break")
+	}
+	decoded, err := Base64MIME.DecodeString(encoded)
+	if err != nil || !bytes.Equal(decoded, data) {
+		t.Errorf("DecodeString() = %v, %v", decoded, err)
+	}
+}
+
+func TestBase64DecodeLenient(t *testing.T) {
+	data := []byte{0xfb, 0xff, 0xbf, 'H', 'e', 'l', 'l', 'o'}
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr error
+	}{
+		{name: "Standard padded", input: "+/+/SGVsbG8="},
+		{name: "Standard unpadded", input: "+/+/SGVsbG8"},
+		{name: "URL safe padded", input: "-_-_SGVsbG8="},
+		{name: "URL safe unpadded", input: "-_-_SGVsbG8"},
+		{name: "Wrapped lines", input: "+/+/\r\nSGVs\nbG8=\n"},
+		{name: "Spaces", input: " +/+/ SGVs bG8= "},
+		{name: "Mixed alphabets", input: "+/-_SGVsbG8", wantErr: ErrMixedAlphabet},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, err := Base64DecodeLenient(tt.input)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Base64DecodeLenient() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Base64DecodeLenient() unexpected error = %v", err)
+			}
+			if !bytes.Equal(decoded, data) {
+				t.Errorf("Base64DecodeLenient() = %v, want %v", decoded, data)
+			}
+		})
+	}
+
+	if _, err := Base64DecodeLenient("Invalid@Base64!"); err == nil {
+		t.Errorf("Base64DecodeLenient() expected error but got none")
+	}
+}
+
+func TestDecodeLenientOtherFamilies(t *testing.T) {
+	tests := []struct {
+		name  string
+		enc   Encoding
+		input string
+	}{
+		{name: "Hex upper case", enc: Hex, input: "48656C6C6F"},
+		{name: "Hex with prefix and spaces", enc: Hex, input: "0x48 65 6c 6c 6f"},
+		{name: "Base32 lower case unpadded", enc: Base32Std, input: "jbswy3dp"},
+		{name: "Base32 padded with spaces", enc: Base32Std, input: "JBSW Y3DP"},
+		{name: "Base32 hex lower case", enc: Base32Hex, input: "91imor3f"},
+		{name: "Base64 family", enc: Base64RawURL, input: "SGVsbG8="},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, err := tt.enc.DecodeLenient(tt.input)
+			if err != nil {
+				t.Fatalf("DecodeLenient() unexpected error = %v", err)
+			}
+			if string(decoded) != "Hello" {
+				t.Errorf("DecodeLenient() = %q, want %q", decoded, "Hello")
+			}
+		})
+	}
+}
+
+func TestStringEncodingHelpers(t *testing.T) {
+	tests := []struct {
+		name     string
+		encode   func(string) string
+		decode   func(string) (string, error)
+		input    string
+		expected string
+	}{
+		{name: "Base64URL", encode: Base64URLEncode, decode: Base64URLDecode, input: "Hello??>", expected: "SGVsbG8_Pz4="},
+		{name: "Hex", encode: HexEncode, decode: HexDecode, input: "Hello", expected: "48656c6c6f"},
+		{name: "Base32", encode: Base32Encode, decode: Base32Decode, input: "Hello", expected: "JBSWY3DP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := tt.encode(tt.input)
+			if encoded != tt.expected {
+				t.Errorf("encode = %v, want %v", encoded, tt.expected)
+			}
+			decoded, err := tt.decode(encoded)
+			if err != nil || decoded != tt.input {
+				t.Errorf("decode = %v, %v, want %v", decoded, err, tt.input)
+			}
+			if _, err := tt.decode("!!!"); err == nil {
+				t.Errorf("decode expected error but got none")
+			}
+		})
+	}
+}
+
+func TestUnknownEncoding(t *testing.T) {
+	if _, err := Encoding(99).DecodeString("abc"); !errors.Is(err, ErrUnknownEncoding) {
+		t.Errorf("DecodeString() error = %v, want %v", err, ErrUnknownEncoding)
+	}
+	if _, err := Encoding(99).DecodeLenient("abc"); !errors.Is(err, ErrUnknownEncoding) {
+		t.Errorf("DecodeLenient() error = %v, want %v", err, ErrUnknownEncoding)
+	}
+}
@@ -0,0 +1,270 @@
+package cipher
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// Envelope layout (all integers big endian):
+//
+//	magic(1) | version(1) | algorithm(1) | keyID(4) | nonce(12) | ciphertext | tag(16)
+//
+// The header (magic to keyID) is authenticated together with the ciphertext, so
+// changing the key ID or algorithm of a stored envelope is detected on decryption.
+const envelopeMagic byte = 0xE7
+
+// EnvelopeVersion is the envelope format version written by this package.
+const EnvelopeVersion byte = 1
+
+const envelopeHeaderSize = 7
+
+// Algorithm identifies the cipher used to produce an envelope.
+type Algorithm byte
+
+// Supported envelope algorithms.
+const (
+	AlgAES256GCM Algorithm = 1
+)
+
+// ErrInvalidEnvelope is returned when data does not look like an envelope produced by this package.
+var ErrInvalidEnvelope = errors.New("invalid envelope")
+
+// ErrUnsupportedEnvelope is returned for envelopes with an unknown version or algorithm.
+var ErrUnsupportedEnvelope = errors.New("unsupported envelope version or algorithm")
+
+// ErrUnknownKey is returned when a key ID is not present in the Keyring.
+var ErrUnknownKey = errors.New("unknown key id")
+
+// ErrNoActiveKey is returned when encrypting with a Keyring that has no active key.
+var ErrNoActiveKey = errors.New("no active key in keyring")
+
+// ErrDuplicateKey is returned when adding a key ID that is already present in the Keyring.
+var ErrDuplicateKey = errors.New("key id already present in keyring")
+
+// Envelope is the parsed form of a self describing ciphertext.
+type Envelope struct {
+	Version   byte
+	Algorithm Algorithm
+	KeyID     uint32
+	Nonce     []byte
+	// Ciphertext holds the encrypted data followed by the authentication tag.
+	Ciphertext []byte
+}
+
+// header returns the authenticated header bytes of the envelope.
+func (e *Envelope) header() []byte {
+	h := make([]byte, envelopeHeaderSize)
+	h[0] = envelopeMagic
+	h[1] = e.Version
+	h[2] = byte(e.Algorithm)
+	binary.BigEndian.PutUint32(h[3:], e.KeyID)
+	return h
+}
+
+// MarshalBinary encodes the envelope into its wire format.
+func (e *Envelope) MarshalBinary() ([]byte, error) {
+	out := make([]byte, 0, envelopeHeaderSize+len(e.Nonce)+len(e.Ciphertext))
+	out = append(out, e.header()...)
+	out = append(out, e.Nonce...)
+	out = append(out, e.Ciphertext...)
+	return out, nil
+}
+
+// IsEnvelope reports whether data starts with an envelope header. It is useful while
+// migrating, to tell envelopes apart from blobs produced by the legacy Encrypt.
+func IsEnvelope(data []byte) bool {
+	return len(data) >= envelopeHeaderSize && data[0] == envelopeMagic
+}
+
+// ParseEnvelope decodes data produced by Keyring.Encrypt without decrypting it.
+func ParseEnvelope(data []byte) (*Envelope, error) {
+	if !IsEnvelope(data) {
+		return nil, ErrInvalidEnvelope
+	}
+	e := &Envelope{
+		Version:   data[1],
+		Algorithm: Algorithm(data[2]),
+		KeyID:     binary.BigEndian.Uint32(data[3:envelopeHeaderSize]),
+	}
+	if e.Version != EnvelopeVersion || e.Algorithm != AlgAES256GCM {
+		return nil, ErrUnsupportedEnvelope
+	}
+	const nonceSize, tagSize = 12, 16
+	body := data[envelopeHeaderSize:]
+	if len(body) < nonceSize+tagSize {
+		return nil, ErrCiphertextTooShort
+	}
+	e.Nonce = body[:nonceSize]
+	e.Ciphertext = body[nonceSize:]
+	return e, nil
+}
+
+// Keyring holds a set of 32 byte keys identified by numeric key IDs.
+//
+// New data is always encrypted with the active key, while data can be decrypted with
+// any key still present in the ring. This allows keys to be rotated by adding a new key,
+// making it active and re-encrypting old data lazily (see Rewrap).
+//
+// A Keyring is safe for concurrent use.
+type Keyring struct {
+	keys      map[uint32][32]byte
+	active    uint32
+	hasActive bool
+	// guards concurrent access to keys and active.
+	sync.RWMutex
+}
+
+// NewKeyring instantiates an empty Keyring.
+func NewKeyring() *Keyring {
+	return &Keyring{keys: make(map[uint32][32]byte)}
+}
+
+// Add inserts a key into the ring. The first key added becomes the active key.
+func (k *Keyring) Add(id uint32, key32 [32]byte) error {
+	k.Lock()
+	defer k.Unlock()
+	if _, found := k.keys[id]; found {
+		return ErrDuplicateKey
+	}
+	k.keys[id] = key32
+	if !k.hasActive {
+		k.active, k.hasActive = id, true
+	}
+	return nil
+}
+
+// SetActive selects the key used for new encryptions.
+func (k *Keyring) SetActive(id uint32) error {
+	k.Lock()
+	defer k.Unlock()
+	if _, found := k.keys[id]; !found {
+		return ErrUnknownKey
+	}
+	k.active, k.hasActive = id, true
+	return nil
+}
+
+// Active returns the ID of the active key. Second return parameter is false if
+// the ring has no active key.
+func (k *Keyring) Active() (uint32, bool) {
+	k.RLock()
+	defer k.RUnlock()
+	return k.active, k.hasActive
+}
+
+// Remove deletes a key from the ring. Data encrypted with it can no longer be decrypted.
+// Removing the active key leaves the ring without an active key.
+func (k *Keyring) Remove(id uint32) {
+	k.Lock()
+	defer k.Unlock()
+	delete(k.keys, id)
+	if k.hasActive && k.active == id {
+		k.hasActive = false
+	}
+}
+
+// IDs returns the key IDs present in the ring, in ascending order.
+func (k *Keyring) IDs() []uint32 {
+	k.RLock()
+	defer k.RUnlock()
+	ids := make([]uint32, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// key returns the key for id, or the active key if active is true.
+func (k *Keyring) key(id uint32, active bool) (uint32, [32]byte, error) {
+	k.RLock()
+	defer k.RUnlock()
+	if active {
+		if !k.hasActive {
+			return 0, [32]byte{}, ErrNoActiveKey
+		}
+		id = k.active
+	}
+	key32, found := k.keys[id]
+	if !found {
+		return 0, [32]byte{}, ErrUnknownKey
+	}
+	return id, key32, nil
+}
+
+// Encrypt seals plaintext with the active key using AES-256-GCM and returns it
+// wrapped in an envelope naming the key ID that was used.
+//
+// additionalData is authenticated but not stored; the same value has to be supplied
+// to Decrypt. It may be nil.
+func (k *Keyring) Encrypt(plaintext, additionalData []byte) ([]byte, error) {
+	id, key32, err := k.key(0, true)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(key32)
+	if err != nil {
+		return nil, err
+	}
+	e := &Envelope{Version: EnvelopeVersion, Algorithm: AlgAES256GCM, KeyID: id}
+	e.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(e.Nonce); err != nil {
+		return nil, err
+	}
+	e.Ciphertext = aead.Seal(nil, e.Nonce, plaintext, append(e.header(), additionalData...))
+	return e.MarshalBinary()
+}
+
+// Decrypt opens an envelope produced by Encrypt using the key it names.
+//
+// ErrUnknownKey is returned if that key is not in the ring, and ErrAuthFailed if the
+// envelope was tampered with or additionalData does not match.
+func (k *Keyring) Decrypt(data, additionalData []byte) ([]byte, error) {
+	e, err := ParseEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+	_, key32, err := k.key(e.KeyID, false)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(key32)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, e.Nonce, e.Ciphertext, append(e.header(), additionalData...))
+	if err != nil {
+		return nil, ErrAuthFailed
+	}
+	return plaintext, nil
+}
+
+// NeedsRewrap reports whether data was encrypted with a key other than the active one.
+func (k *Keyring) NeedsRewrap(data []byte) (bool, error) {
+	e, err := ParseEnvelope(data)
+	if err != nil {
+		return false, err
+	}
+	active, ok := k.Active()
+	if !ok {
+		return false, ErrNoActiveKey
+	}
+	return e.KeyID != active, nil
+}
+
+// Rewrap decrypts data and encrypts it again with the active key. Data that is already
+// encrypted with the active key is returned unchanged.
+func (k *Keyring) Rewrap(data, additionalData []byte) ([]byte, error) {
+	rewrap, err := k.NeedsRewrap(data)
+	if err != nil || !rewrap {
+		return data, err
+	}
+	plaintext, err := k.Decrypt(data, additionalData)
+	if err != nil {
+		return nil, err
+	}
+	return k.Encrypt(plaintext, additionalData)
+}
@@ -0,0 +1,155 @@
+package cipher
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func createTestKeyring(t *testing.T) *Keyring {
+	ring := NewKeyring()
+	if err := ring.Add(1, createTestKey()); err != nil {
+		t.Fatalf("Add() unexpected error = %v", err)
+	}
+	if err := ring.Add(2, createRandomKey()); err != nil {
+		t.Fatalf("Add() unexpected error = %v", err)
+	}
+	return ring
+}
+
+func TestKeyringEncryptDecrypt(t *testing.T) {
+	ring := createTestKeyring(t)
+	plaintext := []byte("Secret message")
+
+	data, err := ring.Encrypt(plaintext, []byte("ad"))
+	if err != nil {
+		t.Fatalf("Encrypt() unexpected error = %v", err)
+	}
+
+	e, err := ParseEnvelope(data)
+	if err != nil {
+		t.Fatalf("ParseEnvelope() unexpected error = %v", err)
+	}
+	if e.Version != EnvelopeVersion || e.Algorithm != AlgAES256GCM || e.KeyID != 1 {
+		t.Errorf("ParseEnvelope() = %+v, want version %d, algorithm %d, key 1", e, EnvelopeVersion, AlgAES256GCM)
+	}
+	if !IsEnvelope(data) {
+		t.Errorf("IsEnvelope() = false, want true")
+	}
+
+	decrypted, err := ring.Decrypt(data, []byte("ad"))
+	if err != nil {
+		t.Fatalf("Decrypt() unexpected error = %v", err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Errorf("Decrypt() = %v, want %v", decrypted, plaintext)
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	ring := createTestKeyring(t)
+	plaintext := []byte("Secret message")
+
+	old, err := ring.Encrypt(plaintext, nil)
+	if err != nil {
+		t.Fatalf("Encrypt() unexpected error = %v", err)
+	}
+	if err := ring.SetActive(2); err != nil {
+		t.Fatalf("SetActive() unexpected error = %v", err)
+	}
+
+	// Old data still decrypts with the key it names.
+	decrypted, err := ring.Decrypt(old, nil)
+	if err != nil || !bytes.Equal(decrypted, plaintext) {
+		t.Fatalf("Decrypt() of old envelope = %v, %v", decrypted, err)
+	}
+
+	rewrap, err := ring.NeedsRewrap(old)
+	if err != nil || !rewrap {
+		t.Errorf("NeedsRewrap() = %v, %v, want true", rewrap, err)
+	}
+	fresh, err := ring.Rewrap(old, nil)
+	if err != nil {
+		t.Fatalf("Rewrap() unexpected error = %v", err)
+	}
+	e, _ := ParseEnvelope(fresh)
+	if e.KeyID != 2 {
+		t.Errorf("Rewrap() key id = %d, want 2", e.KeyID)
+	}
+
+	ring.Remove(1)
+	if _, err := ring.Decrypt(old, nil); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Decrypt() with removed key error = %v, want %v", err, ErrUnknownKey)
+	}
+	decrypted, err = ring.Decrypt(fresh, nil)
+	if err != nil || !bytes.Equal(decrypted, plaintext) {
+		t.Errorf("Decrypt() of rewrapped envelope = %v, %v", decrypted, err)
+	}
+}
+
+func TestKeyringErrors(t *testing.T) {
+	ring := createTestKeyring(t)
+	data, err := ring.Encrypt([]byte("Hello, World!"), nil)
+	if err != nil {
+		t.Fatalf("Encrypt() unexpected error = %v", err)
+	}
+	legacy, _ := Encrypt(createTestKey(), []byte("Hello, World!"))
+
+	// Pointing the envelope at another key must not go unnoticed.
+	swapped := append([]byte(nil), data...)
+	swapped[6] = 2
+
+	unsupported := append([]byte(nil), data...)
+	unsupported[1] = EnvelopeVersion + 1
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{
+			name:    "Legacy ciphertext",
+			data:    legacy,
+			wantErr: ErrInvalidEnvelope,
+		},
+		{
+			name:    "Empty data",
+			data:    []byte{},
+			wantErr: ErrInvalidEnvelope,
+		},
+		{
+			name:    "Unsupported version",
+			data:    unsupported,
+			wantErr: ErrUnsupportedEnvelope,
+		},
+		{
+			name:    "Swapped key id",
+			data:    swapped,
+			wantErr: ErrAuthFailed,
+		},
+		{
+			name:    "Truncated envelope",
+			data:    data[:envelopeHeaderSize+4],
+			wantErr: ErrCiphertextTooShort,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ring.Decrypt(tt.data, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Decrypt() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := ring.Add(1, createRandomKey()); !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("Add() duplicate error = %v, want %v", err, ErrDuplicateKey)
+	}
+	if err := ring.SetActive(9); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("SetActive() error = %v, want %v", err, ErrUnknownKey)
+	}
+	if _, err := NewKeyring().Encrypt([]byte("x"), nil); !errors.Is(err, ErrNoActiveKey) {
+		t.Errorf("Encrypt() on empty keyring error = %v, want %v", err, ErrNoActiveKey)
+	}
+}
@@ -0,0 +1,64 @@
+package cipher
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+)
+
+// ErrAuthFailed is returned when a ciphertext could not be authenticated. This happens
+// when the data was tampered with, or when the wrong key or associated data was supplied.
+var ErrAuthFailed = errors.New("message authentication failed")
+
+// ErrCiphertextTooShort is returned when the supplied ciphertext is shorter than the
+// minimum size produced by the matching encrypt function.
+var ErrCiphertextTooShort = errors.New("ciphertext too short")
+
+// newGCM returns an AES-256-GCM AEAD for the given key.
+func newGCM(key32 [32]byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key32[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Seal encrypts and authenticates plaintext with AES-256-GCM.
+//
+// A fresh random nonce is generated for every call, so sealing the same plaintext twice
+// yields different outputs. The returned slice is nonce||ciphertext||tag.
+//
+// additionalData is authenticated but not encrypted; the same value has to be supplied
+// to Open. It may be nil.
+func Seal(key32 [32]byte, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := newGCM(key32)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(out); err != nil {
+		return nil, err
+	}
+	return aead.Seal(out, out, plaintext, additionalData), nil
+}
+
+// Open authenticates and decrypts a ciphertext produced by Seal.
+//
+// ErrAuthFailed is returned if the ciphertext was modified, or the key or
+// additionalData do not match the ones used with Seal.
+func Open(key32 [32]byte, ciphertext, additionalData []byte) ([]byte, error) {
+	aead, err := newGCM(key32)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrCiphertextTooShort
+	}
+	nonce := ciphertext[:aead.NonceSize()]
+	plaintext, err := aead.Open(nil, nonce, ciphertext[aead.NonceSize():], additionalData)
+	if err != nil {
+		return nil, ErrAuthFailed
+	}
+	return plaintext, nil
+}
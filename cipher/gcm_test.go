@@ -0,0 +1,167 @@
+package cipher
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	key := createTestKey()
+
+	tests := []struct {
+		name string
+		text []byte
+		ad   []byte
+	}{
+		{
+			name: "Simple text",
+			text: []byte("Hello, World!"),
+		},
+		{
+			name: "Empty text",
+			text: []byte{},
+		},
+		{
+			name: "Large text",
+			text: bytes.Repeat([]byte("A"), 10000),
+		},
+		{
+			name: "Binary data with associated data",
+			text: []byte{0, 1, 2, 3, 255, 254, 253},
+			ad:   []byte("user:42"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sealed, err := Seal(key, tt.text, tt.ad)
+			if err != nil {
+				t.Fatalf("Seal() unexpected error = %v", err)
+			}
+			if len(tt.text) > 0 && bytes.Contains(sealed, tt.text) {
+				t.Errorf("Seal() output contains the plaintext")
+			}
+			opened, err := Open(key, sealed, tt.ad)
+			if err != nil {
+				t.Fatalf("Open() unexpected error = %v", err)
+			}
+			if !bytes.Equal(opened, tt.text) {
+				t.Errorf("Open() = %v, want %v", opened, tt.text)
+			}
+		})
+	}
+}
+
+func TestSealUsesRandomNonce(t *testing.T) {
+	key := createTestKey()
+	plaintext := []byte("Secret message")
+
+	first, err := Seal(key, plaintext, nil)
+	if err != nil {
+		t.Fatalf("Seal() unexpected error = %v", err)
+	}
+	second, err := Seal(key, plaintext, nil)
+	if err != nil {
+		t.Fatalf("Seal() unexpected error = %v", err)
+	}
+	if bytes.Equal(first[:12], second[:12]) {
+		t.Errorf("Seal() reused the nonce across calls")
+	}
+	if bytes.Equal(first, second) {
+		t.Errorf("Seal() produced identical outputs for the same plaintext")
+	}
+}
+
+func TestOpenRejectsTampering(t *testing.T) {
+	key := createTestKey()
+	sealed, err := Seal(key, []byte("Hello, World!"), []byte("ad"))
+	if err != nil {
+		t.Fatalf("Seal() unexpected error = %v", err)
+	}
+
+	flip := func(i int) []byte {
+		c := append([]byte(nil), sealed...)
+		c[i] ^= 0x01
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		key     [32]byte
+		text    []byte
+		ad      []byte
+		wantErr error
+	}{
+		{
+			name:    "Modified nonce",
+			key:     key,
+			text:    flip(0),
+			ad:      []byte("ad"),
+			wantErr: ErrAuthFailed,
+		},
+		{
+			name:    "Modified ciphertext",
+			key:     key,
+			text:    flip(14),
+			ad:      []byte("ad"),
+			wantErr: ErrAuthFailed,
+		},
+		{
+			name:    "Modified tag",
+			key:     key,
+			text:    flip(len(sealed) - 1),
+			ad:      []byte("ad"),
+			wantErr: ErrAuthFailed,
+		},
+		{
+			name:    "Wrong associated data",
+			key:     key,
+			text:    sealed,
+			ad:      []byte("other"),
+			wantErr: ErrAuthFailed,
+		},
+		{
+			name:    "Wrong key",
+			key:     createRandomKey(),
+			text:    sealed,
+			ad:      []byte("ad"),
+			wantErr: ErrAuthFailed,
+		},
+		{
+			name:    "Ciphertext too short",
+			key:     key,
+			text:    sealed[:20],
+			ad:      []byte("ad"),
+			wantErr: ErrCiphertextTooShort,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Open(tt.key, tt.text, tt.ad)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Open() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func BenchmarkSeal(b *testing.B) {
+	key := createTestKey()
+	data := []byte("Hello, World! This is a benchmark test for encryption.")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Seal(key, data, nil)
+	}
+}
+
+func BenchmarkOpen(b *testing.B) {
+	key := createTestKey()
+	data := []byte("Hello, World! This is a benchmark test for decryption.")
+	sealed, _ := Seal(key, data, nil)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Open(key, sealed, nil)
+	}
+}
@@ -0,0 +1,33 @@
+package cipher
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+)
+
+// HMACSHA256 returns the HMAC-SHA256 of message under key.
+func HMACSHA256(key, message []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(message)
+	return mac.Sum(nil)
+}
+
+// HMACSHA512 returns the HMAC-SHA512 of message under key.
+func HMACSHA512(key, message []byte) []byte {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(message)
+	return mac.Sum(nil)
+}
+
+// VerifyHMACSHA256 reports whether mac is the HMAC-SHA256 of message under key.
+// The comparison is constant time.
+func VerifyHMACSHA256(key, message, mac []byte) bool {
+	return hmac.Equal(HMACSHA256(key, message), mac)
+}
+
+// VerifyHMACSHA512 reports whether mac is the HMAC-SHA512 of message under key.
+// The comparison is constant time.
+func VerifyHMACSHA512(key, message, mac []byte) bool {
+	return hmac.Equal(HMACSHA512(key, message), mac)
+}
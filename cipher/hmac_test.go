@@ -0,0 +1,54 @@
+package cipher
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestHMAC(t *testing.T) {
+	// Test case 2 from RFC 4231.
+	key := []byte("Jefe")
+	message := []byte("what do ya want for nothing?")
+
+	tests := []struct {
+		name     string
+		sign     func(key, message []byte) []byte
+		verify   func(key, message, mac []byte) bool
+		expected string
+	}{
+		{
+			name:     "HMAC-SHA256",
+			sign:     HMACSHA256,
+			verify:   VerifyHMACSHA256,
+			expected: "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+		},
+		{
+			name:   "HMAC-SHA512",
+			sign:   HMACSHA512,
+			verify: VerifyHMACSHA512,
+			expected: "164b7a7bfcf819e2e395fbe73b56e0a387bd64222e831fd610270cd7ea250554" +
+				"9758bf75c05a994a6d034f65f8f0e6fdcaeab1a34d4a6b4b636e070a38bce737",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mac := tt.sign(key, message)
+			if got := hex.EncodeToString(mac); got != tt.expected {
+				t.Errorf("sign = %v, want %v", got, tt.expected)
+			}
+			if !tt.verify(key, message, mac) {
+				t.Errorf("verify = false for a valid mac")
+			}
+			if tt.verify([]byte("other"), message, mac) {
+				t.Errorf("verify = true with the wrong key")
+			}
+			if tt.verify(key, []byte("what do ya want for something?"), mac) {
+				t.Errorf("verify = true for a modified message")
+			}
+			if tt.verify(key, message, mac[:len(mac)-1]) {
+				t.Errorf("verify = true for a truncated mac")
+			}
+		})
+	}
+}
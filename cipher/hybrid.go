@@ -0,0 +1,192 @@
+package cipher
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+)
+
+// Hybrid envelope layout (all integers big endian):
+//
+//	magic(1) | version(1) | algorithm(1) | ephemeralKey(32) | count(2) | wrappedKey(48) * count | nonce(12) | ciphertext | tag(16)
+//
+// A random data key encrypts the plaintext with AES-256-GCM. For every recipient the
+// data key is wrapped with a key derived via HKDF-SHA256 from the X25519 shared secret
+// between the ephemeral key and the recipient's key. Recipients are not named in the
+// envelope; OpenWith tries every wrapped key.
+const (
+	// AlgX25519Hybrid identifies envelopes produced by SealTo and SealToMany.
+	AlgX25519Hybrid Algorithm = 4
+
+	hybridKeySize     = 32
+	hybridWrappedSize = 32 + 16
+	hybridMaxCount    = 1<<16 - 1
+	hybridKDFInfo     = "gowraps x25519 hybrid v1"
+)
+
+// ErrNotRecipient is returned by OpenWith when none of the wrapped keys in an envelope
+// belongs to the supplied private key.
+var ErrNotRecipient = errors.New("not a recipient of this envelope")
+
+// ErrNoRecipients is returned by SealToMany when no or too many recipients are given.
+var ErrNoRecipients = errors.New("invalid number of recipients")
+
+// GenerateX25519Key generates a new X25519 key pair for use with SealTo and OpenWith.
+// The public key is available via its PublicKey method.
+func GenerateX25519Key() (*ecdh.PrivateKey, error) {
+	return ecdh.X25519().GenerateKey(rand.Reader)
+}
+
+// isX25519Key reports whether key is an X25519 private or public key.
+func isX25519Key(key interface{}) bool {
+	switch k := key.(type) {
+	case *ecdh.PrivateKey:
+		return k != nil && k.Curve() == ecdh.X25519()
+	case *ecdh.PublicKey:
+		return k != nil && k.Curve() == ecdh.X25519()
+	}
+	return false
+}
+
+// SealTo encrypts plaintext so that only the holder of the private key matching
+// recipient can decrypt it with OpenWith.
+func SealTo(recipient *ecdh.PublicKey, plaintext []byte) ([]byte, error) {
+	return SealToMany([]*ecdh.PublicKey{recipient}, plaintext)
+}
+
+// SealToMany is same as SealTo but any of the recipients can decrypt the envelope.
+// The plaintext is encrypted only once, each recipient adds 48 bytes to the output.
+func SealToMany(recipients []*ecdh.PublicKey, plaintext []byte) ([]byte, error) {
+	if len(recipients) == 0 || len(recipients) > hybridMaxCount {
+		return nil, ErrNoRecipients
+	}
+	for _, r := range recipients {
+		if !isX25519Key(r) {
+			return nil, ErrUnsupportedKey
+		}
+	}
+	ephemeral, err := GenerateX25519Key()
+	if err != nil {
+		return nil, err
+	}
+	var dataKey [32]byte
+	if _, err := rand.Read(dataKey[:]); err != nil {
+		return nil, err
+	}
+
+	header := []byte{envelopeMagic, EnvelopeVersion, byte(AlgX25519Hybrid)}
+	header = append(header, ephemeral.PublicKey().Bytes()...)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(recipients)))
+	prefix := append([]byte(nil), header...)
+	for _, r := range recipients {
+		shared, err := ephemeral.ECDH(r)
+		if err != nil {
+			return nil, err
+		}
+		kek, err := hybridKEK(shared, ephemeral.PublicKey(), r)
+		if err != nil {
+			return nil, err
+		}
+		header = kek.Seal(header, make([]byte, kek.NonceSize()), dataKey[:], prefix)
+	}
+
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randomBytes(aead.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(header)+len(nonce)+len(plaintext)+aead.Overhead())
+	out = append(out, header...)
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, plaintext, header), nil
+}
+
+// OpenWith decrypts an envelope produced by SealTo or SealToMany with the private key
+// of one of its recipients.
+//
+// ErrNotRecipient is returned if the envelope was not sealed to priv, and ErrAuthFailed
+// if it was tampered with.
+func OpenWith(priv *ecdh.PrivateKey, envelope []byte) ([]byte, error) {
+	if !isX25519Key(priv) {
+		return nil, ErrUnsupportedKey
+	}
+	const fixed = 3 + hybridKeySize + 2
+	if len(envelope) < 3 || envelope[0] != envelopeMagic {
+		return nil, ErrInvalidEnvelope
+	}
+	if envelope[1] != EnvelopeVersion || Algorithm(envelope[2]) != AlgX25519Hybrid {
+		return nil, ErrUnsupportedEnvelope
+	}
+	if len(envelope) < fixed {
+		return nil, ErrCiphertextTooShort
+	}
+	count := int(binary.BigEndian.Uint16(envelope[fixed-2:]))
+	headerSize := fixed + count*hybridWrappedSize
+	if count == 0 || len(envelope) < headerSize+12+16 {
+		return nil, ErrCiphertextTooShort
+	}
+	ephemeral, err := ecdh.X25519().NewPublicKey(envelope[3 : 3+hybridKeySize])
+	if err != nil {
+		return nil, ErrInvalidEnvelope
+	}
+	shared, err := priv.ECDH(ephemeral)
+	if err != nil {
+		// The ephemeral key is a low order point; nobody can be a recipient.
+		return nil, ErrAuthFailed
+	}
+	kek, err := hybridKEK(shared, ephemeral, priv.PublicKey())
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, header := envelope[:fixed], envelope[:headerSize]
+	var dataKey [32]byte
+	found := false
+	zeroNonce := make([]byte, kek.NonceSize())
+	for i := 0; i < count && !found; i++ {
+		wrapped := envelope[fixed+i*hybridWrappedSize : fixed+(i+1)*hybridWrappedSize]
+		if key, err := kek.Open(nil, zeroNonce, wrapped, prefix); err == nil {
+			copy(dataKey[:], key)
+			found = true
+		}
+	}
+	if !found {
+		return nil, ErrNotRecipient
+	}
+
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	body := envelope[headerSize:]
+	plaintext, err := aead.Open(nil, body[:aead.NonceSize()], body[aead.NonceSize():], header)
+	if err != nil {
+		return nil, ErrAuthFailed
+	}
+	return plaintext, nil
+}
+
+// hybridKEK derives the key wrapping AEAD from an X25519 shared secret. The ephemeral
+// and recipient keys are bound into the derivation, so a wrapped key cannot be moved to
+// another envelope or recipient. The derived key is unique per envelope and recipient,
+// which makes the all-zero nonce used for wrapping safe.
+func hybridKEK(shared []byte, ephemeral, recipient *ecdh.PublicKey) (cipher.AEAD, error) {
+	salt := append(append([]byte(nil), ephemeral.Bytes()...), recipient.Bytes()...)
+	key, err := hkdf.Key(sha256.New, shared, salt, hybridKDFInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
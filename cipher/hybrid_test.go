@@ -0,0 +1,136 @@
+package cipher
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"errors"
+	"testing"
+)
+
+func createTestX25519Key(t *testing.T) *ecdh.PrivateKey {
+	key, err := GenerateX25519Key()
+	if err != nil {
+		t.Fatalf("GenerateX25519Key() unexpected error = %v", err)
+	}
+	return key
+}
+
+func TestSealToOpenWith(t *testing.T) {
+	recipient := createTestX25519Key(t)
+	plaintext := []byte("database password for the reporting team")
+
+	envelope, err := SealTo(recipient.PublicKey(), plaintext)
+	if err != nil {
+		t.Fatalf("SealTo() unexpected error = %v", err)
+	}
+	if bytes.Contains(envelope, plaintext) {
+		t.Errorf("SealTo() output contains the plaintext")
+	}
+	opened, err := OpenWith(recipient, envelope)
+	if err != nil {
+		t.Fatalf("OpenWith() unexpected error = %v", err)
+	}
+	if !bytes.Equal(opened, plaintext) {
+		t.Errorf("OpenWith() = %s, want %s", opened, plaintext)
+	}
+
+	if _, err := OpenWith(createTestX25519Key(t), envelope); !errors.Is(err, ErrNotRecipient) {
+		t.Errorf("OpenWith() by a stranger error = %v, want %v", err, ErrNotRecipient)
+	}
+}
+
+func TestSealToMany(t *testing.T) {
+	recipients := []*ecdh.PrivateKey{createTestX25519Key(t), createTestX25519Key(t), createTestX25519Key(t)}
+	publics := make([]*ecdh.PublicKey, len(recipients))
+	for i, r := range recipients {
+		publics[i] = r.PublicKey()
+	}
+	plaintext := []byte("shared secret")
+
+	envelope, err := SealToMany(publics, plaintext)
+	if err != nil {
+		t.Fatalf("SealToMany() unexpected error = %v", err)
+	}
+	for i, r := range recipients {
+		opened, err := OpenWith(r, envelope)
+		if err != nil {
+			t.Fatalf("OpenWith() by recipient %d unexpected error = %v", i, err)
+		}
+		if !bytes.Equal(opened, plaintext) {
+			t.Errorf("OpenWith() by recipient %d = %s, want %s", i, opened, plaintext)
+		}
+	}
+
+	if _, err := SealToMany(nil, plaintext); !errors.Is(err, ErrNoRecipients) {
+		t.Errorf("SealToMany() without recipients error = %v, want %v", err, ErrNoRecipients)
+	}
+}
+
+func TestOpenWithTampering(t *testing.T) {
+	recipient := createTestX25519Key(t)
+	envelope, err := SealTo(recipient.PublicKey(), []byte("Hello, World!"))
+	if err != nil {
+		t.Fatalf("SealTo() unexpected error = %v", err)
+	}
+	flip := func(i int) []byte {
+		c := append([]byte(nil), envelope...)
+		c[i] ^= 0x01
+		return c
+	}
+	const fixed = 3 + hybridKeySize + 2
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{name: "Modified ephemeral key", data: flip(5), wantErr: ErrNotRecipient},
+		{name: "Modified wrapped key", data: flip(fixed + 3), wantErr: ErrNotRecipient},
+		{name: "Modified ciphertext", data: flip(len(envelope) - 20), wantErr: ErrAuthFailed},
+		{name: "Modified tag", data: flip(len(envelope) - 1), wantErr: ErrAuthFailed},
+		{name: "Truncated", data: envelope[:fixed+10], wantErr: ErrCiphertextTooShort},
+		{name: "Not an envelope", data: []byte("plain text"), wantErr: ErrInvalidEnvelope},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := OpenWith(recipient, tt.data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("OpenWith() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestX25519KeyPEM(t *testing.T) {
+	key := createTestX25519Key(t)
+	privPEM, err := MarshalPrivateKeyPEM(key)
+	if err != nil {
+		t.Fatalf("MarshalPrivateKeyPEM() unexpected error = %v", err)
+	}
+	pubPEM, err := MarshalPublicKeyPEM(key.PublicKey())
+	if err != nil {
+		t.Fatalf("MarshalPublicKeyPEM() unexpected error = %v", err)
+	}
+	pub, err := UnmarshalPublicKeyPEM(pubPEM)
+	if err != nil {
+		t.Fatalf("UnmarshalPublicKeyPEM() unexpected error = %v", err)
+	}
+	priv, err := UnmarshalPrivateKeyPEM(privPEM)
+	if err != nil {
+		t.Fatalf("UnmarshalPrivateKeyPEM() unexpected error = %v", err)
+	}
+
+	envelope, err := SealTo(pub.(*ecdh.PublicKey), []byte("via PEM"))
+	if err != nil {
+		t.Fatalf("SealTo() unexpected error = %v", err)
+	}
+	if _, err := OpenWith(priv.(*ecdh.PrivateKey), envelope); err != nil {
+		t.Errorf("OpenWith() unexpected error = %v", err)
+	}
+
+	// X25519 keys cannot sign.
+	if _, err := Sign(key, []byte("m")); !errors.Is(err, ErrUnsupportedKey) {
+		t.Errorf("Sign() with X25519 key error = %v, want %v", err, ErrUnsupportedKey)
+	}
+}
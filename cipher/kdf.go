@@ -0,0 +1,275 @@
+package cipher
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+)
+
+// KDF identifies a password based key derivation function.
+type KDF byte
+
+// Supported key derivation functions.
+const (
+	KDFPBKDF2SHA256 KDF = 1
+	KDFScrypt       KDF = 2
+)
+
+// Default cost parameters, following current OWASP recommendations.
+const (
+	DefaultPBKDF2Iterations = 600000
+	DefaultScryptLogN       = 15
+	DefaultScryptR          = 8
+	DefaultScryptP          = 1
+
+	kdfSaltSize = 16
+)
+
+// Upper bounds accepted when reading parameters back, so that a crafted ciphertext
+// cannot make DecryptWithPassphrase allocate gigabytes of memory or spin for hours.
+// scrypt needs 128*R*N bytes of memory and does about N*R*P block mixes, both are
+// capped on top of the individual parameters.
+const (
+	maxPBKDF2Iterations = 10000000
+	maxScryptLogN       = 20
+	maxScryptR          = 32
+	maxScryptP          = 16
+	maxScryptMemory     = 256 << 20
+	maxScryptWork       = 1 << 24
+	maxKDFSaltSize      = 255
+)
+
+// AlgPassphraseAES256GCM identifies data produced by EncryptWithPassphrase.
+const AlgPassphraseAES256GCM Algorithm = 3
+
+// ErrInvalidKDFParams is returned for unknown, malformed or out of range KDF parameters.
+var ErrInvalidKDFParams = errors.New("invalid key derivation parameters")
+
+// KDFParams holds everything needed to derive the same key from a passphrase again.
+// It is safe to store alongside the data; only the passphrase is secret.
+type KDFParams struct {
+	Algorithm KDF
+	Salt      []byte
+	// Iterations is the PBKDF2 iteration count.
+	Iterations int
+	// LogN, R and P are the scrypt cost parameters, N being 1<<LogN.
+	LogN int
+	R    int
+	P    int
+}
+
+// NewPBKDF2Params returns PBKDF2-SHA256 parameters with a fresh random salt and the default iteration count.
+func NewPBKDF2Params() (KDFParams, error) {
+	salt, err := randomBytes(kdfSaltSize)
+	if err != nil {
+		return KDFParams{}, err
+	}
+	return KDFParams{Algorithm: KDFPBKDF2SHA256, Salt: salt, Iterations: DefaultPBKDF2Iterations}, nil
+}
+
+// NewScryptParams returns scrypt parameters with a fresh random salt and the default cost.
+func NewScryptParams() (KDFParams, error) {
+	salt, err := randomBytes(kdfSaltSize)
+	if err != nil {
+		return KDFParams{}, err
+	}
+	return KDFParams{Algorithm: KDFScrypt, Salt: salt, LogN: DefaultScryptLogN, R: DefaultScryptR, P: DefaultScryptP}, nil
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// validate checks the parameters are known and within sane bounds.
+func (p KDFParams) validate() error {
+	if len(p.Salt) == 0 || len(p.Salt) > maxKDFSaltSize {
+		return ErrInvalidKDFParams
+	}
+	switch p.Algorithm {
+	case KDFPBKDF2SHA256:
+		if p.Iterations < 1 || p.Iterations > maxPBKDF2Iterations {
+			return ErrInvalidKDFParams
+		}
+	case KDFScrypt:
+		if !validScryptCost(p.LogN, p.R, p.P) {
+			return ErrInvalidKDFParams
+		}
+	default:
+		return ErrInvalidKDFParams
+	}
+	return nil
+}
+
+// validScryptCost tells whether scrypt can run with these parameters within the
+// memory and CPU bounds. Checking each parameter first keeps the products from
+// overflowing.
+func validScryptCost(logN, r, p int) bool {
+	if logN < 1 || logN > maxScryptLogN || r < 1 || r > maxScryptR || p < 1 || p > maxScryptP {
+		return false
+	}
+	n := 1 << logN
+	return 128*r*n <= maxScryptMemory && n*r*p <= maxScryptWork
+}
+
+// DeriveKey derives a 32 byte key from passphrase, suitable for Seal, Keyring and the
+// stream functions.
+func DeriveKey(passphrase string, params KDFParams) ([32]byte, error) {
+	var key32 [32]byte
+	if err := params.validate(); err != nil {
+		return key32, err
+	}
+	var key []byte
+	var err error
+	switch params.Algorithm {
+	case KDFPBKDF2SHA256:
+		key, err = pbkdf2.Key(sha256.New, passphrase, params.Salt, params.Iterations, len(key32))
+	case KDFScrypt:
+		key, err = scrypt([]byte(passphrase), params.Salt, 1<<params.LogN, params.R, params.P, len(key32))
+	}
+	if err != nil {
+		return key32, err
+	}
+	copy(key32[:], key)
+	return key32, nil
+}
+
+// MarshalBinary encodes the parameters as
+//
+//	algorithm(1) | saltLen(1) | salt | iterations(4)             for PBKDF2
+//	algorithm(1) | saltLen(1) | salt | logN(1) | r(4) | p(4)     for scrypt
+func (p KDFParams) MarshalBinary() ([]byte, error) {
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, 2+len(p.Salt)+9)
+	out = append(out, byte(p.Algorithm), byte(len(p.Salt)))
+	out = append(out, p.Salt...)
+	switch p.Algorithm {
+	case KDFPBKDF2SHA256:
+		out = binary.BigEndian.AppendUint32(out, uint32(p.Iterations))
+	case KDFScrypt:
+		out = append(out, byte(p.LogN))
+		out = binary.BigEndian.AppendUint32(out, uint32(p.R))
+		out = binary.BigEndian.AppendUint32(out, uint32(p.P))
+	}
+	return out, nil
+}
+
+// UnmarshalBinary decodes parameters encoded with MarshalBinary.
+func (p *KDFParams) UnmarshalBinary(data []byte) error {
+	_, err := p.unmarshal(data)
+	return err
+}
+
+// unmarshal decodes the parameters at the start of data and returns their encoded length.
+func (p *KDFParams) unmarshal(data []byte) (int, error) {
+	if len(data) < 2 || len(data) < 2+int(data[1]) {
+		return 0, ErrInvalidKDFParams
+	}
+	params := KDFParams{Algorithm: KDF(data[0])}
+	n := 2 + int(data[1])
+	params.Salt = append([]byte(nil), data[2:n]...)
+	switch params.Algorithm {
+	case KDFPBKDF2SHA256:
+		if len(data) < n+4 {
+			return 0, ErrInvalidKDFParams
+		}
+		params.Iterations = int(binary.BigEndian.Uint32(data[n:]))
+		n += 4
+	case KDFScrypt:
+		if len(data) < n+9 {
+			return 0, ErrInvalidKDFParams
+		}
+		params.LogN = int(data[n])
+		params.R = int(binary.BigEndian.Uint32(data[n+1:]))
+		params.P = int(binary.BigEndian.Uint32(data[n+5:]))
+		n += 9
+	default:
+		return 0, ErrInvalidKDFParams
+	}
+	if err := params.validate(); err != nil {
+		return 0, err
+	}
+	*p = params
+	return n, nil
+}
+
+// EncryptWithPassphrase derives a key from passphrase with scrypt and a random salt,
+// and seals plaintext with it. The KDF parameters are stored in the output, so only
+// the passphrase is needed to decrypt it with DecryptWithPassphrase.
+func EncryptWithPassphrase(passphrase string, plaintext []byte) ([]byte, error) {
+	params, err := NewScryptParams()
+	if err != nil {
+		return nil, err
+	}
+	return EncryptWithPassphraseParams(passphrase, plaintext, params)
+}
+
+// EncryptWithPassphraseParams is same as EncryptWithPassphrase but uses the given KDF
+// parameters, which is useful to pick PBKDF2 or a different cost.
+//
+// The output layout is magic(1) | version(1) | algorithm(1) | params | nonce(12) | ciphertext | tag(16),
+// the header up to and including params being authenticated.
+func EncryptWithPassphraseParams(passphrase string, plaintext []byte, params KDFParams) ([]byte, error) {
+	encoded, err := params.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	key32, err := DeriveKey(passphrase, params)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(key32)
+	if err != nil {
+		return nil, err
+	}
+	header := append([]byte{envelopeMagic, EnvelopeVersion, byte(AlgPassphraseAES256GCM)}, encoded...)
+	nonce, err := randomBytes(aead.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(header)+len(nonce)+len(plaintext)+aead.Overhead())
+	out = append(out, header...)
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, plaintext, header), nil
+}
+
+// DecryptWithPassphrase decrypts data produced by EncryptWithPassphrase.
+//
+// ErrAuthFailed is returned if the passphrase is wrong or the data was tampered with.
+func DecryptWithPassphrase(passphrase string, data []byte) ([]byte, error) {
+	if len(data) < 3 || data[0] != envelopeMagic {
+		return nil, ErrInvalidEnvelope
+	}
+	if data[1] != EnvelopeVersion || Algorithm(data[2]) != AlgPassphraseAES256GCM {
+		return nil, ErrUnsupportedEnvelope
+	}
+	var params KDFParams
+	n, err := params.unmarshal(data[3:])
+	if err != nil {
+		return nil, err
+	}
+	header, body := data[:3+n], data[3+n:]
+	key32, err := DeriveKey(passphrase, params)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(key32)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrCiphertextTooShort
+	}
+	plaintext, err := aead.Open(nil, body[:aead.NonceSize()], body[aead.NonceSize():], header)
+	if err != nil {
+		return nil, ErrAuthFailed
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,168 @@
+package cipher
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// cheap parameters keep the tests fast; production code uses the defaults.
+func createTestScryptParams() KDFParams {
+	return KDFParams{Algorithm: KDFScrypt, Salt: []byte("0123456789abcdef"), LogN: 4, R: 8, P: 1}
+}
+
+func createTestPBKDF2Params() KDFParams {
+	return KDFParams{Algorithm: KDFPBKDF2SHA256, Salt: []byte("0123456789abcdef"), Iterations: 10}
+}
+
+func TestDeriveKey(t *testing.T) {
+	// PBKDF2-HMAC-SHA256 vector from RFC 7914, section 11.
+	key, err := DeriveKey("passwd", KDFParams{Algorithm: KDFPBKDF2SHA256, Salt: []byte("salt"), Iterations: 1})
+	if err != nil {
+		t.Fatalf("DeriveKey() unexpected error = %v", err)
+	}
+	expected := "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc"
+	if got := hex.EncodeToString(key[:]); got != expected {
+		t.Errorf("DeriveKey() = %v, want %v", got, expected)
+	}
+
+	first, _ := DeriveKey("passphrase", createTestScryptParams())
+	second, _ := DeriveKey("passphrase", createTestScryptParams())
+	if first != second {
+		t.Errorf("DeriveKey() is not deterministic for the same params")
+	}
+	other, _ := DeriveKey("other", createTestScryptParams())
+	if first == other {
+		t.Errorf("DeriveKey() returned the same key for different passphrases")
+	}
+}
+
+func TestDeriveKeyInvalidParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		params KDFParams
+	}{
+		{name: "Unknown algorithm", params: KDFParams{Algorithm: 9, Salt: []byte("salt")}},
+		{name: "Missing salt", params: KDFParams{Algorithm: KDFPBKDF2SHA256, Iterations: 10}},
+		{name: "Zero iterations", params: KDFParams{Algorithm: KDFPBKDF2SHA256, Salt: []byte("salt")}},
+		{name: "Scrypt cost too high", params: KDFParams{Algorithm: KDFScrypt, Salt: []byte("salt"), LogN: 30, R: 8, P: 1}},
+		{name: "Scrypt R too high", params: KDFParams{Algorithm: KDFScrypt, Salt: []byte("salt"), LogN: 10, R: 65536, P: 1}},
+		{name: "Scrypt P too high", params: KDFParams{Algorithm: KDFScrypt, Salt: []byte("salt"), LogN: 10, R: 8, P: 64}},
+		{name: "Scrypt memory too high", params: KDFParams{Algorithm: KDFScrypt, Salt: []byte("salt"), LogN: 20, R: 8, P: 1}},
+		{name: "Scrypt work too high", params: KDFParams{Algorithm: KDFScrypt, Salt: []byte("salt"), LogN: 18, R: 8, P: 16}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DeriveKey("pw", tt.params); !errors.Is(err, ErrInvalidKDFParams) {
+				t.Errorf("DeriveKey() error = %v, want %v", err, ErrInvalidKDFParams)
+			}
+		})
+	}
+}
+
+func TestKDFParamsMarshalRoundTrip(t *testing.T) {
+	for _, params := range []KDFParams{createTestScryptParams(), createTestPBKDF2Params()} {
+		encoded, err := params.MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary() unexpected error = %v", err)
+		}
+		var decoded KDFParams
+		if err := decoded.UnmarshalBinary(encoded); err != nil {
+			t.Fatalf("UnmarshalBinary() unexpected error = %v", err)
+		}
+		if decoded.Algorithm != params.Algorithm || !bytes.Equal(decoded.Salt, params.Salt) ||
+			decoded.Iterations != params.Iterations || decoded.LogN != params.LogN ||
+			decoded.R != params.R || decoded.P != params.P {
+			t.Errorf("Round trip failed: got %+v, want %+v", decoded, params)
+		}
+	}
+}
+
+func TestNewKDFParams(t *testing.T) {
+	first, err := NewScryptParams()
+	if err != nil {
+		t.Fatalf("NewScryptParams() unexpected error = %v", err)
+	}
+	second, _ := NewScryptParams()
+	if bytes.Equal(first.Salt, second.Salt) {
+		t.Errorf("NewScryptParams() reused the salt")
+	}
+	if first.LogN != DefaultScryptLogN || first.R != DefaultScryptR || first.P != DefaultScryptP {
+		t.Errorf("NewScryptParams() = %+v, want default cost", first)
+	}
+	pbkdf2, err := NewPBKDF2Params()
+	if err != nil || pbkdf2.Iterations != DefaultPBKDF2Iterations {
+		t.Errorf("NewPBKDF2Params() = %+v, %v", pbkdf2, err)
+	}
+}
+
+func TestPassphraseRoundTrip(t *testing.T) {
+	plaintext := []byte("Secret message")
+
+	tests := []struct {
+		name   string
+		params KDFParams
+	}{
+		{name: "Scrypt", params: createTestScryptParams()},
+		{name: "PBKDF2", params: createTestPBKDF2Params()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encrypted, err := EncryptWithPassphraseParams("correct horse", plaintext, tt.params)
+			if err != nil {
+				t.Fatalf("EncryptWithPassphraseParams() unexpected error = %v", err)
+			}
+			decrypted, err := DecryptWithPassphrase("correct horse", encrypted)
+			if err != nil {
+				t.Fatalf("DecryptWithPassphrase() unexpected error = %v", err)
+			}
+			if !bytes.Equal(decrypted, plaintext) {
+				t.Errorf("DecryptWithPassphrase() = %v, want %v", decrypted, plaintext)
+			}
+			if _, err := DecryptWithPassphrase("wrong horse", encrypted); !errors.Is(err, ErrAuthFailed) {
+				t.Errorf("DecryptWithPassphrase() with wrong passphrase error = %v, want %v", err, ErrAuthFailed)
+			}
+
+			// Modifying the stored salt must be detected.
+			tampered := append([]byte(nil), encrypted...)
+			tampered[5] ^= 0x01
+			if _, err := DecryptWithPassphrase("correct horse", tampered); !errors.Is(err, ErrAuthFailed) {
+				t.Errorf("DecryptWithPassphrase() of tampered data error = %v, want %v", err, ErrAuthFailed)
+			}
+		})
+	}
+}
+
+func TestDecryptWithPassphraseCraftedCost(t *testing.T) {
+	// scrypt with N=2^20 and R=65536 would need 8 TiB of memory, the header must be
+	// rejected before deriving any key.
+	envelope, _ := hex.DecodeString("e70103" + "0210" + "30313233343536373839616263646566" +
+		"14" + "00010000" + "00000001" + strings.Repeat("00", 28))
+
+	start := time.Now()
+	if _, err := DecryptWithPassphrase("pw", envelope); !errors.Is(err, ErrInvalidKDFParams) {
+		t.Errorf("DecryptWithPassphrase() error = %v, want %v", err, ErrInvalidKDFParams)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("DecryptWithPassphrase() took %v, scrypt must not run", elapsed)
+	}
+}
+
+func TestEncryptWithPassphraseDefaults(t *testing.T) {
+	if testing.Short() {
+		t.Skip("default scrypt cost is slow")
+	}
+	encrypted, err := EncryptWithPassphrase("correct horse", []byte("Secret message"))
+	if err != nil {
+		t.Fatalf("EncryptWithPassphrase() unexpected error = %v", err)
+	}
+	decrypted, err := DecryptWithPassphrase("correct horse", encrypted)
+	if err != nil || string(decrypted) != "Secret message" {
+		t.Errorf("DecryptWithPassphrase() = %q, %v", decrypted, err)
+	}
+}
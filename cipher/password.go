@@ -0,0 +1,169 @@
+package cipher
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrPasswordMismatch is returned by VerifyPassword when the password does not match the hash.
+var ErrPasswordMismatch = errors.New("password does not match")
+
+// ErrInvalidHash is returned when an encoded password hash cannot be parsed.
+var ErrInvalidHash = errors.New("invalid password hash")
+
+// PasswordHasher hashes passwords with scrypt and encodes the result as a PHC string:
+//
+//	$scrypt$ln=15,r=8,p=1$<salt>$<hash>
+//
+// salt and hash are base64 encoded without padding. Since the cost parameters are part
+// of the string, they can be raised at any time; existing hashes keep verifying and
+// NeedsRehash tells which ones to upgrade on the next successful login.
+type PasswordHasher struct {
+	LogN    int
+	R       int
+	P       int
+	SaltLen int
+	KeyLen  int
+}
+
+// DefaultPasswordHasher is used by HashPassword, VerifyPassword and NeedsRehash.
+var DefaultPasswordHasher = PasswordHasher{
+	LogN:    DefaultScryptLogN,
+	R:       DefaultScryptR,
+	P:       DefaultScryptP,
+	SaltLen: kdfSaltSize,
+	KeyLen:  32,
+}
+
+const (
+	minPasswordHashLen = 16
+	maxPasswordHashLen = 64
+)
+
+// HashPassword hashes password using DefaultPasswordHasher.
+func HashPassword(password string) (string, error) {
+	return DefaultPasswordHasher.Hash(password)
+}
+
+// VerifyPassword checks password against a hash produced by HashPassword. It returns
+// nil on a match and ErrPasswordMismatch otherwise.
+func VerifyPassword(password, encoded string) error {
+	return DefaultPasswordHasher.Verify(password, encoded)
+}
+
+// NeedsRehash reports whether encoded was produced with parameters other than the
+// ones of DefaultPasswordHasher.
+func NeedsRehash(encoded string) (bool, error) {
+	return DefaultPasswordHasher.NeedsRehash(encoded)
+}
+
+// passwordHash is the decoded form of a PHC string.
+type passwordHash struct {
+	params KDFParams
+	hash   []byte
+}
+
+// Hash hashes password with a fresh random salt.
+func (h PasswordHasher) Hash(password string) (string, error) {
+	if h.KeyLen < minPasswordHashLen || h.KeyLen > maxPasswordHashLen {
+		return "", ErrInvalidKDFParams
+	}
+	salt, err := randomBytes(h.SaltLen)
+	if err != nil {
+		return "", err
+	}
+	params := KDFParams{Algorithm: KDFScrypt, Salt: salt, LogN: h.LogN, R: h.R, P: h.P}
+	if err := params.validate(); err != nil {
+		return "", err
+	}
+	hash, err := scrypt([]byte(password), salt, 1<<h.LogN, h.R, h.P, h.KeyLen)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("$scrypt$ln=%d,r=%d,p=%d$%s$%s", h.LogN, h.R, h.P,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(hash)), nil
+}
+
+// Verify checks password against encoded using the parameters stored in encoded, so
+// hashes made with older settings still verify. The comparison is constant time.
+func (h PasswordHasher) Verify(password, encoded string) error {
+	ph, err := parsePasswordHash(encoded)
+	if err != nil {
+		return err
+	}
+	p := ph.params
+	hash, err := scrypt([]byte(password), p.Salt, 1<<p.LogN, p.R, p.P, len(ph.hash))
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(hash, ph.hash) != 1 {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+// NeedsRehash reports whether encoded was produced with parameters other than the
+// hasher's, typically after the cost has been raised.
+func (h PasswordHasher) NeedsRehash(encoded string) (bool, error) {
+	ph, err := parsePasswordHash(encoded)
+	if err != nil {
+		return false, err
+	}
+	p := ph.params
+	return p.LogN != h.LogN || p.R != h.R || p.P != h.P ||
+		len(p.Salt) != h.SaltLen || len(ph.hash) != h.KeyLen, nil
+}
+
+// parsePasswordHash decodes a PHC string produced by Hash. The cost parameters are
+// checked against the same bounds as KDFParams, so a hostile hash stored in the
+// database cannot make Verify exhaust memory.
+func parsePasswordHash(encoded string) (*passwordHash, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 5 || parts[0] != "" || parts[1] != "scrypt" {
+		return nil, ErrInvalidHash
+	}
+	params := KDFParams{Algorithm: KDFScrypt}
+	seen := 0
+	for _, kv := range strings.Split(parts[2], ",") {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, ErrInvalidHash
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, ErrInvalidHash
+		}
+		switch name {
+		case "ln":
+			params.LogN = n
+		case "r":
+			params.R = n
+		case "p":
+			params.P = n
+		default:
+			return nil, ErrInvalidHash
+		}
+		seen++
+	}
+	if seen != 3 {
+		return nil, ErrInvalidHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return nil, ErrInvalidHash
+	}
+	hash, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil || len(hash) < minPasswordHashLen || len(hash) > maxPasswordHashLen {
+		return nil, ErrInvalidHash
+	}
+	params.Salt = salt
+	if err := params.validate(); err != nil {
+		return nil, ErrInvalidHash
+	}
+	return &passwordHash{params: params, hash: hash}, nil
+}
@@ -0,0 +1,105 @@
+package cipher
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// cheap parameters keep the tests fast; production code uses the defaults.
+var testPasswordHasher = PasswordHasher{LogN: 4, R: 8, P: 1, SaltLen: 16, KeyLen: 32}
+
+func TestPasswordHashVerify(t *testing.T) {
+	encoded, err := testPasswordHasher.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash() unexpected error = %v", err)
+	}
+	if !strings.HasPrefix(encoded, "$scrypt$ln=4,r=8,p=1$") {
+		t.Errorf("Hash() = %v, want PHC string with scrypt params", encoded)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  error
+	}{
+		{name: "Correct password", password: "correct horse", wantErr: nil},
+		{name: "Wrong password", password: "wrong horse", wantErr: ErrPasswordMismatch},
+		{name: "Empty password", password: "", wantErr: ErrPasswordMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := testPasswordHasher.Verify(tt.password, encoded)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	again, _ := testPasswordHasher.Hash("correct horse")
+	if again == encoded {
+		t.Errorf("Hash() reused the salt")
+	}
+}
+
+func TestVerifyPasswordInvalidHash(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{name: "Empty string", encoded: ""},
+		{name: "Other algorithm", encoded: "$argon2id$v=19$m=65536,t=3,p=4$c2FsdA$aGFzaA"},
+		{name: "Missing param", encoded: "$scrypt$ln=4,r=8$c2FsdHNhbHRzYWx0c2FsdA$MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY"},
+		{name: "Unknown param", encoded: "$scrypt$ln=4,r=8,x=1$c2FsdHNhbHRzYWx0c2FsdA$MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY"},
+		{name: "Cost too high", encoded: "$scrypt$ln=40,r=8,p=1$c2FsdHNhbHRzYWx0c2FsdA$MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY"},
+		{name: "Memory too high", encoded: "$scrypt$ln=20,r=65536,p=1$c2FsdHNhbHRzYWx0c2FsdA$MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY"},
+		{name: "Parallelism too high", encoded: "$scrypt$ln=4,r=8,p=1000$c2FsdHNhbHRzYWx0c2FsdA$MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY"},
+		{name: "Bad base64", encoded: "$scrypt$ln=4,r=8,p=1$!!!$MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY"},
+		{name: "Short hash", encoded: "$scrypt$ln=4,r=8,p=1$c2FsdHNhbHRzYWx0c2FsdA$aGFzaA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifyPassword("pw", tt.encoded); !errors.Is(err, ErrInvalidHash) {
+				t.Errorf("VerifyPassword() error = %v, want %v", err, ErrInvalidHash)
+			}
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	encoded, err := testPasswordHasher.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash() unexpected error = %v", err)
+	}
+
+	raised := testPasswordHasher
+	raised.LogN++
+
+	tests := []struct {
+		name     string
+		hasher   PasswordHasher
+		expected bool
+	}{
+		{name: "Same parameters", hasher: testPasswordHasher, expected: false},
+		{name: "Raised cost", hasher: raised, expected: true},
+		{name: "Default parameters", hasher: DefaultPasswordHasher, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.hasher.NeedsRehash(encoded)
+			if err != nil {
+				t.Fatalf("NeedsRehash() unexpected error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("NeedsRehash() = %v, want %v", result, tt.expected)
+			}
+			// Old hashes keep verifying after the cost was raised.
+			if err := tt.hasher.Verify("correct horse", encoded); err != nil {
+				t.Errorf("Verify() unexpected error = %v", err)
+			}
+		})
+	}
+}
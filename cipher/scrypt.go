@@ -0,0 +1,117 @@
+package cipher
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/bits"
+)
+
+// scrypt implements the scrypt key derivation function as described in RFC 7914.
+//
+// n is the CPU/memory cost and has to be a power of two greater than one, r is the
+// block size and p the parallelization factor. Memory use is roughly 128*r*n bytes.
+func scrypt(password, salt []byte, n, r, p, keyLen int) ([]byte, error) {
+	if n <= 1 || n&(n-1) != 0 {
+		return nil, errors.New("scrypt: N must be a power of 2 greater than 1")
+	}
+	const maxInt = int(^uint(0) >> 1)
+	if r <= 0 || p <= 0 || uint64(r)*uint64(p) >= 1<<30 || r > maxInt/128/p || r > maxInt/256 || n > maxInt/128/r {
+		return nil, errors.New("scrypt: parameters are too large")
+	}
+
+	xy := make([]uint32, 64*r)
+	v := make([]uint32, 32*n*r)
+	b, err := pbkdf2.Key(sha256.New, string(password), salt, 1, p*128*r)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < p; i++ {
+		smix(b[i*128*r:], r, n, v, xy)
+	}
+	return pbkdf2.Key(sha256.New, string(password), b, 1, keyLen)
+}
+
+// smix performs scryptROMix on the 128*r byte block b.
+func smix(b []byte, r, n int, v, xy []uint32) {
+	var tmp [16]uint32
+	size := 32 * r
+	x := xy
+	y := xy[size:]
+
+	for i := 0; i < size; i++ {
+		x[i] = binary.LittleEndian.Uint32(b[i*4:])
+	}
+	for i := 0; i < n; i += 2 {
+		copy(v[i*size:], x[:size])
+		blockMix(&tmp, x, y, r)
+		copy(v[(i+1)*size:], y[:size])
+		blockMix(&tmp, y, x, r)
+	}
+	for i := 0; i < n; i += 2 {
+		j := int(integerify(x, r) & uint64(n-1))
+		blockXOR(x, v[j*size:], size)
+		blockMix(&tmp, x, y, r)
+
+		j = int(integerify(y, r) & uint64(n-1))
+		blockXOR(y, v[j*size:], size)
+		blockMix(&tmp, y, x, r)
+	}
+	for i := 0; i < size; i++ {
+		binary.LittleEndian.PutUint32(b[i*4:], x[i])
+	}
+}
+
+// blockMix performs scryptBlockMix on in, writing the result to out.
+func blockMix(tmp *[16]uint32, in, out []uint32, r int) {
+	copy(tmp[:], in[(2*r-1)*16:(2*r)*16])
+	for i := 0; i < 2*r; i += 2 {
+		salsaXOR(tmp, in[i*16:], out[i*8:])
+		salsaXOR(tmp, in[i*16+16:], out[i*8+r*16:])
+	}
+}
+
+func blockXOR(dst, src []uint32, n int) {
+	for i, v := range src[:n] {
+		dst[i] ^= v
+	}
+}
+
+func integerify(b []uint32, r int) uint64 {
+	j := (2*r - 1) * 16
+	return uint64(b[j]) | uint64(b[j+1])<<32
+}
+
+// salsaXOR sets tmp to Salsa20/8(tmp XOR in) and copies the result to out.
+func salsaXOR(tmp *[16]uint32, in, out []uint32) {
+	var w, x [16]uint32
+	for i := range w {
+		w[i] = tmp[i] ^ in[i]
+	}
+	x = w
+	for i := 0; i < 8; i += 2 {
+		// column round
+		quarterRound(&x, 0, 4, 8, 12)
+		quarterRound(&x, 5, 9, 13, 1)
+		quarterRound(&x, 10, 14, 2, 6)
+		quarterRound(&x, 15, 3, 7, 11)
+		// row round
+		quarterRound(&x, 0, 1, 2, 3)
+		quarterRound(&x, 5, 6, 7, 4)
+		quarterRound(&x, 10, 11, 8, 9)
+		quarterRound(&x, 15, 12, 13, 14)
+	}
+	for i := range x {
+		x[i] += w[i]
+		out[i] = x[i]
+	}
+	*tmp = x
+}
+
+func quarterRound(x *[16]uint32, a, b, c, d int) {
+	x[b] ^= bits.RotateLeft32(x[a]+x[d], 7)
+	x[c] ^= bits.RotateLeft32(x[b]+x[a], 9)
+	x[d] ^= bits.RotateLeft32(x[c]+x[b], 13)
+	x[a] ^= bits.RotateLeft32(x[d]+x[c], 18)
+}
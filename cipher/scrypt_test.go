@@ -0,0 +1,66 @@
+package cipher
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestScryptVectors(t *testing.T) {
+	// Test vectors from RFC 7914, section 12.
+	tests := []struct {
+		name     string
+		password string
+		salt     string
+		n, r, p  int
+		expected string
+	}{
+		{
+			name:     "Empty password and salt",
+			password: "",
+			salt:     "",
+			n:        16, r: 1, p: 1,
+			expected: "77d6576238657b203b19ca42c18a0497f16b4844e3074ae8dfdffa3fede21442" +
+				"fcd0069ded0948f8326a753a0fc81f17e8d3e0fb2e0d3628cf35e20c38d18906",
+		},
+		{
+			name:     "Password NaCl",
+			password: "password",
+			salt:     "NaCl",
+			n:        1024, r: 8, p: 16,
+			expected: "fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b373162" +
+				"2eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := scrypt([]byte(tt.password), []byte(tt.salt), tt.n, tt.r, tt.p, 64)
+			if err != nil {
+				t.Fatalf("scrypt() unexpected error = %v", err)
+			}
+			if got := hex.EncodeToString(key); got != tt.expected {
+				t.Errorf("scrypt() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScryptInvalidParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		n, r, p int
+	}{
+		{name: "N not a power of two", n: 1000, r: 8, p: 1},
+		{name: "N too small", n: 1, r: 8, p: 1},
+		{name: "Zero r", n: 16, r: 0, p: 1},
+		{name: "r*p too large", n: 16, r: 1 << 15, p: 1 << 15},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := scrypt([]byte("pw"), []byte("salt"), tt.n, tt.r, tt.p, 32); err == nil {
+				t.Errorf("scrypt() expected error but got none")
+			}
+		})
+	}
+}
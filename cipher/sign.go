@@ -0,0 +1,188 @@
+package cipher
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+)
+
+// ErrInvalidSignature is returned by Verify when a signature does not match the message.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// ErrUnsupportedKey is returned for key types other than Ed25519 and ECDSA P-256, or
+// X25519 where encryption keys are expected.
+var ErrUnsupportedKey = errors.New("unsupported key type")
+
+// ErrInvalidPEM is returned when no PEM block of the expected type could be decoded.
+var ErrInvalidPEM = errors.New("invalid PEM data")
+
+// PEM block types written and read by this package.
+const (
+	pemPrivateKey   = "PRIVATE KEY"
+	pemECPrivateKey = "EC PRIVATE KEY"
+	pemPublicKey    = "PUBLIC KEY"
+)
+
+// GenerateEd25519Key generates a new Ed25519 key pair. The public key is available
+// via its Public method.
+func GenerateEd25519Key() (ed25519.PrivateKey, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	return priv, err
+}
+
+// GenerateECDSAKey generates a new ECDSA key pair on the P-256 curve.
+func GenerateECDSAKey() (*ecdsa.PrivateKey, error) {
+	return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+}
+
+// checkKey makes sure key is one of the supported private or public key types.
+func checkKey(key interface{}) error {
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		if len(k) == ed25519.PrivateKeySize {
+			return nil
+		}
+	case ed25519.PublicKey:
+		if len(k) == ed25519.PublicKeySize {
+			return nil
+		}
+	case *ecdsa.PrivateKey:
+		if k.Curve == elliptic.P256() {
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		if k.Curve == elliptic.P256() {
+			return nil
+		}
+	}
+	return ErrUnsupportedKey
+}
+
+// checkPEMKey makes sure key is a signing key or an X25519 key used by SealTo.
+func checkPEMKey(key interface{}) error {
+	if isX25519Key(key) {
+		return nil
+	}
+	return checkKey(key)
+}
+
+// MarshalPrivateKeyPEM encodes a private key as a PKCS#8 "PRIVATE KEY" PEM block.
+func MarshalPrivateKeyPEM(key crypto.PrivateKey) ([]byte, error) {
+	if err := checkPEMKey(key); err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: pemPrivateKey, Bytes: der}), nil
+}
+
+// UnmarshalPrivateKeyPEM decodes the first private key PEM block in data. Both PKCS#8
+// "PRIVATE KEY" and SEC 1 "EC PRIVATE KEY" blocks, as written by openssl, are accepted.
+// The returned key is an ed25519.PrivateKey, an *ecdsa.PrivateKey or an X25519 *ecdh.PrivateKey.
+func UnmarshalPrivateKeyPEM(data []byte) (crypto.PrivateKey, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, ErrInvalidPEM
+		}
+		var key crypto.PrivateKey
+		var err error
+		switch block.Type {
+		case pemPrivateKey:
+			key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		case pemECPrivateKey:
+			key, err = x509.ParseECPrivateKey(block.Bytes)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := checkPEMKey(key); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+}
+
+// MarshalPublicKeyPEM encodes a public key as a PKIX "PUBLIC KEY" PEM block.
+func MarshalPublicKeyPEM(key crypto.PublicKey) ([]byte, error) {
+	if err := checkPEMKey(key); err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: pemPublicKey, Bytes: der}), nil
+}
+
+// UnmarshalPublicKeyPEM decodes the first "PUBLIC KEY" PEM block in data. The returned
+// key is an ed25519.PublicKey, an *ecdsa.PublicKey or an X25519 *ecdh.PublicKey.
+func UnmarshalPublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, ErrInvalidPEM
+		}
+		if block.Type != pemPublicKey {
+			continue
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkPEMKey(key); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+}
+
+// Sign signs message with an Ed25519 or ECDSA P-256 private key. ECDSA signatures
+// are computed over the SHA-256 digest of message and ASN.1 DER encoded.
+func Sign(key crypto.PrivateKey, message []byte) ([]byte, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		return ed25519.Sign(k, message), nil
+	case *ecdsa.PrivateKey:
+		digest := sha256.Sum256(message)
+		return ecdsa.SignASN1(rand.Reader, k, digest[:])
+	}
+	return nil, ErrUnsupportedKey
+}
+
+// Verify checks a signature produced by Sign. It returns nil if the signature is valid
+// and ErrInvalidSignature otherwise.
+func Verify(key crypto.PublicKey, message, signature []byte) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	var valid bool
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(k, message, signature)
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(message)
+		valid = ecdsa.VerifyASN1(k, digest[:], signature)
+	default:
+		return ErrUnsupportedKey
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+	return nil
+}
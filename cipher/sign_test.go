@@ -0,0 +1,130 @@
+package cipher
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+)
+
+func createTestSigningKeys(t *testing.T) map[string]crypto.Signer {
+	edKey, err := GenerateEd25519Key()
+	if err != nil {
+		t.Fatalf("GenerateEd25519Key() unexpected error = %v", err)
+	}
+	ecKey, err := GenerateECDSAKey()
+	if err != nil {
+		t.Fatalf("GenerateECDSAKey() unexpected error = %v", err)
+	}
+	return map[string]crypto.Signer{"Ed25519": edKey, "ECDSA P-256": ecKey}
+}
+
+func TestSignVerify(t *testing.T) {
+	message := []byte(`{"event":"payment.succeeded","id":42}`)
+
+	for name, key := range createTestSigningKeys(t) {
+		t.Run(name, func(t *testing.T) {
+			signature, err := Sign(key, message)
+			if err != nil {
+				t.Fatalf("Sign() unexpected error = %v", err)
+			}
+			if err := Verify(key.Public(), message, signature); err != nil {
+				t.Errorf("Verify() unexpected error = %v", err)
+			}
+
+			tampered := append([]byte(nil), message...)
+			tampered[0] ^= 0x01
+			if err := Verify(key.Public(), tampered, signature); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("Verify() of modified message error = %v, want %v", err, ErrInvalidSignature)
+			}
+
+			others := createTestSigningKeys(t)
+			if err := Verify(others[name].Public(), message, signature); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("Verify() with another key error = %v, want %v", err, ErrInvalidSignature)
+			}
+		})
+	}
+}
+
+func TestKeyPEMRoundTrip(t *testing.T) {
+	message := []byte("webhook payload")
+
+	for name, key := range createTestSigningKeys(t) {
+		t.Run(name, func(t *testing.T) {
+			privPEM, err := MarshalPrivateKeyPEM(key)
+			if err != nil {
+				t.Fatalf("MarshalPrivateKeyPEM() unexpected error = %v", err)
+			}
+			pubPEM, err := MarshalPublicKeyPEM(key.Public())
+			if err != nil {
+				t.Fatalf("MarshalPublicKeyPEM() unexpected error = %v", err)
+			}
+
+			priv, err := UnmarshalPrivateKeyPEM(privPEM)
+			if err != nil {
+				t.Fatalf("UnmarshalPrivateKeyPEM() unexpected error = %v", err)
+			}
+			pub, err := UnmarshalPublicKeyPEM(append(privPEM, pubPEM...))
+			if err != nil {
+				t.Fatalf("UnmarshalPublicKeyPEM() unexpected error = %v", err)
+			}
+
+			signature, err := Sign(priv, message)
+			if err != nil {
+				t.Fatalf("Sign() with parsed key unexpected error = %v", err)
+			}
+			if err := Verify(pub, message, signature); err != nil {
+				t.Errorf("Verify() with parsed key unexpected error = %v", err)
+			}
+			if err := Verify(key.Public(), message, signature); err != nil {
+				t.Errorf("Verify() with original key unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func TestUnmarshalECPrivateKeyPEM(t *testing.T) {
+	key, _ := GenerateECDSAKey()
+	der, _ := x509.MarshalECPrivateKey(key)
+	data := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+
+	parsed, err := UnmarshalPrivateKeyPEM(data)
+	if err != nil {
+		t.Fatalf("UnmarshalPrivateKeyPEM() unexpected error = %v", err)
+	}
+	if !key.Equal(parsed) {
+		t.Errorf("UnmarshalPrivateKeyPEM() returned a different key")
+	}
+}
+
+func TestUnsupportedKeys(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 1024)
+	p384Key, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	rsaDER, _ := x509.MarshalPKCS8PrivateKey(rsaKey)
+	rsaPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: rsaDER})
+
+	if _, err := Sign(rsaKey, []byte("m")); !errors.Is(err, ErrUnsupportedKey) {
+		t.Errorf("Sign() with RSA key error = %v, want %v", err, ErrUnsupportedKey)
+	}
+	if _, err := Sign(p384Key, []byte("m")); !errors.Is(err, ErrUnsupportedKey) {
+		t.Errorf("Sign() with P-384 key error = %v, want %v", err, ErrUnsupportedKey)
+	}
+	if err := Verify(ed25519.PublicKey{1, 2, 3}, []byte("m"), []byte("s")); !errors.Is(err, ErrUnsupportedKey) {
+		t.Errorf("Verify() with short key error = %v, want %v", err, ErrUnsupportedKey)
+	}
+	if _, err := MarshalPrivateKeyPEM(rsaKey); !errors.Is(err, ErrUnsupportedKey) {
+		t.Errorf("MarshalPrivateKeyPEM() with RSA key error = %v, want %v", err, ErrUnsupportedKey)
+	}
+	if _, err := UnmarshalPrivateKeyPEM(rsaPEM); !errors.Is(err, ErrUnsupportedKey) {
+		t.Errorf("UnmarshalPrivateKeyPEM() with RSA key error = %v, want %v", err, ErrUnsupportedKey)
+	}
+	if _, err := UnmarshalPublicKeyPEM([]byte("not pem")); !errors.Is(err, ErrInvalidPEM) {
+		t.Errorf("UnmarshalPublicKeyPEM() error = %v, want %v", err, ErrInvalidPEM)
+	}
+}
@@ -0,0 +1,246 @@
+package cipher
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Stream layout (all integers big endian):
+//
+//	magic(1) | version(1) | algorithm(1) | segmentSize(4) | noncePrefix(7) | segment...
+//
+// Every segment holds up to segmentSize bytes of plaintext sealed with AES-256-GCM, so
+// it is segmentSize+16 bytes long except for the final one, which may be shorter. The
+// nonce of a segment is noncePrefix | counter(4) | lastFlag(1) and the stream header is
+// passed as associated data, so reordering, dropping, duplicating or truncating
+// segments is detected while decrypting.
+const (
+	// AlgAES256GCMStream identifies the segmented streaming format.
+	AlgAES256GCMStream Algorithm = 2
+
+	streamHeaderSize        = 14
+	streamNoncePrefixSize   = 7
+	defaultStreamSegmentLen = 64 * 1024
+	maxStreamSegmentLen     = 16 * 1024 * 1024
+)
+
+// ErrStreamTooLong is returned when a stream would need more segments than the nonce counter allows.
+var ErrStreamTooLong = errors.New("stream too long")
+
+// ErrStreamClosed is returned when writing to an encrypt writer that was already closed.
+var ErrStreamClosed = errors.New("write to closed stream")
+
+// segmentNonce fills nonce for the segment with the given counter.
+func segmentNonce(nonce, prefix []byte, counter uint32, last bool) {
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[streamNoncePrefixSize:], counter)
+	nonce[len(nonce)-1] = 0
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+}
+
+type encryptWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	header  []byte
+	nonce   []byte
+	counter uint32
+	buf     []byte
+	out     []byte
+	closed  bool
+	err     error
+}
+
+// NewEncryptWriter returns a writer that encrypts everything written to it and writes
+// the result to w. Data is processed in individually authenticated segments, so memory
+// use stays constant regardless of the amount of data.
+//
+// Close has to be called to write the final segment; without it the stream cannot be
+// decrypted. Close does not close w.
+func NewEncryptWriter(key32 [32]byte, w io.Writer) (io.WriteCloser, error) {
+	return newEncryptWriter(key32, w, defaultStreamSegmentLen)
+}
+
+func newEncryptWriter(key32 [32]byte, w io.Writer, segmentSize int) (*encryptWriter, error) {
+	aead, err := newGCM(key32)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, streamHeaderSize)
+	header[0] = envelopeMagic
+	header[1] = EnvelopeVersion
+	header[2] = byte(AlgAES256GCMStream)
+	binary.BigEndian.PutUint32(header[3:], uint32(segmentSize))
+	if _, err := rand.Read(header[7:]); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{
+		w:      w,
+		aead:   aead,
+		header: header,
+		nonce:  make([]byte, aead.NonceSize()),
+		buf:    make([]byte, 0, segmentSize),
+		out:    make([]byte, 0, segmentSize+aead.Overhead()),
+	}, nil
+}
+
+// Write encrypts p. A full segment is held back until more data arrives or the writer
+// is closed, because only then is it known whether it is the final one.
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, ErrStreamClosed
+	}
+	if e.err != nil {
+		return 0, e.err
+	}
+	var n int
+	for len(p) > 0 {
+		if len(e.buf) == cap(e.buf) {
+			if err := e.flush(false); err != nil {
+				return n, err
+			}
+		}
+		k := copy(e.buf[len(e.buf):cap(e.buf)], p)
+		e.buf = e.buf[:len(e.buf)+k]
+		p = p[k:]
+		n += k
+	}
+	return n, nil
+}
+
+// Close writes the final segment. It does not close the underlying writer.
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return e.err
+	}
+	e.closed = true
+	if e.err != nil {
+		return e.err
+	}
+	return e.flush(true)
+}
+
+func (e *encryptWriter) flush(last bool) error {
+	if !last && e.counter == ^uint32(0) {
+		e.err = ErrStreamTooLong
+		return e.err
+	}
+	segmentNonce(e.nonce, e.header[7:], e.counter, last)
+	e.out = e.aead.Seal(e.out[:0], e.nonce, e.buf, e.header)
+	if _, err := e.w.Write(e.out); err != nil {
+		e.err = err
+		return err
+	}
+	e.counter++
+	e.buf = e.buf[:0]
+	return nil
+}
+
+type decryptReader struct {
+	r       io.Reader
+	aead    cipher.AEAD
+	header  []byte
+	nonce   []byte
+	counter uint32
+	// buf holds one segment plus one byte read ahead to detect the final segment.
+	buf     []byte
+	carried int
+	out     []byte
+	plain   []byte
+	done    bool
+	err     error
+}
+
+// NewDecryptReader returns a reader that decrypts a stream produced by NewEncryptWriter
+// from r. Each segment is authenticated before any of its plaintext is returned.
+//
+// ErrAuthFailed is returned from Read if the stream was modified or truncated, or the
+// key does not match.
+func NewDecryptReader(key32 [32]byte, r io.Reader) (io.Reader, error) {
+	aead, err := newGCM(key32)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, streamHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrInvalidEnvelope
+		}
+		return nil, err
+	}
+	if header[0] != envelopeMagic {
+		return nil, ErrInvalidEnvelope
+	}
+	if header[1] != EnvelopeVersion || Algorithm(header[2]) != AlgAES256GCMStream {
+		return nil, ErrUnsupportedEnvelope
+	}
+	segmentSize := binary.BigEndian.Uint32(header[3:])
+	if segmentSize == 0 || segmentSize > maxStreamSegmentLen {
+		return nil, ErrInvalidEnvelope
+	}
+	return &decryptReader{
+		r:      r,
+		aead:   aead,
+		header: header,
+		nonce:  make([]byte, aead.NonceSize()),
+		buf:    make([]byte, int(segmentSize)+aead.Overhead()+1),
+	}, nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		d.err = d.next()
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+// next decrypts the following segment into d.plain.
+func (d *decryptReader) next() error {
+	if d.done {
+		return io.EOF
+	}
+	n, err := io.ReadFull(d.r, d.buf[d.carried:])
+	size := d.carried + n
+	last := false
+	switch err {
+	case nil:
+		// A whole segment plus the look ahead byte; more data follows.
+		size--
+	case io.EOF, io.ErrUnexpectedEOF:
+		last = true
+	default:
+		return err
+	}
+	if size < d.aead.Overhead() {
+		return ErrAuthFailed
+	}
+	if !last && d.counter == ^uint32(0) {
+		return ErrStreamTooLong
+	}
+	segmentNonce(d.nonce, d.header[7:], d.counter, last)
+	plain, err := d.aead.Open(d.out[:0], d.nonce, d.buf[:size], d.header)
+	if err != nil {
+		return ErrAuthFailed
+	}
+	d.out, d.plain = plain, plain
+	d.counter++
+	d.done = last
+	d.carried = 0
+	if !last {
+		d.buf[0] = d.buf[size]
+		d.carried = 1
+	}
+	return nil
+}
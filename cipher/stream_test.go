@@ -0,0 +1,205 @@
+package cipher
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+// encryptStream encrypts data with the given segment size, writing it in chunks of writeSize.
+func encryptStream(t *testing.T, key [32]byte, data []byte, segmentSize, writeSize int) []byte {
+	var buf bytes.Buffer
+	w, err := newEncryptWriter(key, &buf, segmentSize)
+	if err != nil {
+		t.Fatalf("newEncryptWriter() unexpected error = %v", err)
+	}
+	for len(data) > 0 {
+		n := min(writeSize, len(data))
+		if _, err := w.Write(data[:n]); err != nil {
+			t.Fatalf("Write() unexpected error = %v", err)
+		}
+		data = data[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() unexpected error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func decryptStream(key [32]byte, data []byte) ([]byte, error) {
+	r, err := NewDecryptReader(key, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	key := createTestKey()
+	const segment = 64
+
+	random := make([]byte, 10*segment+7)
+	rand.Read(random)
+
+	tests := []struct {
+		name      string
+		size      int
+		writeSize int
+	}{
+		{name: "Empty stream", size: 0, writeSize: 1},
+		{name: "Less than a segment", size: segment - 1, writeSize: 7},
+		{name: "Exactly one segment", size: segment, writeSize: segment},
+		{name: "One segment plus one byte", size: segment + 1, writeSize: 3},
+		{name: "Exact multiple of segments", size: 4 * segment, writeSize: 100},
+		{name: "Many segments", size: len(random), writeSize: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := random[:tt.size]
+			encrypted := encryptStream(t, key, data, segment, tt.writeSize)
+
+			decrypted, err := decryptStream(key, encrypted)
+			if err != nil {
+				t.Fatalf("decrypt unexpected error = %v", err)
+			}
+			if !bytes.Equal(decrypted, data) {
+				t.Errorf("Round trip failed: got %d bytes, want %d", len(decrypted), len(data))
+			}
+		})
+	}
+}
+
+func TestStreamSmallReads(t *testing.T) {
+	key := createTestKey()
+	data := bytes.Repeat([]byte("Long text test "), 100)
+	encrypted := encryptStream(t, key, data, 32, 11)
+
+	r, err := NewDecryptReader(key, iotest.OneByteReader(bytes.NewReader(encrypted)))
+	if err != nil {
+		t.Fatalf("NewDecryptReader() unexpected error = %v", err)
+	}
+	decrypted, err := io.ReadAll(iotest.OneByteReader(r))
+	if err != nil {
+		t.Fatalf("ReadAll() unexpected error = %v", err)
+	}
+	if !bytes.Equal(decrypted, data) {
+		t.Errorf("Round trip failed: got %q, want %q", decrypted, data)
+	}
+}
+
+func TestStreamDetectsTampering(t *testing.T) {
+	key := createTestKey()
+	const segment = 16
+	data := bytes.Repeat([]byte("0123456789abcdef"), 4)
+	encrypted := encryptStream(t, key, data, segment, len(data))
+	sealedSegment := segment + 16
+
+	body := func() []byte { return append([]byte(nil), encrypted[streamHeaderSize:]...) }
+	withBody := func(b []byte) []byte {
+		return append(append([]byte(nil), encrypted[:streamHeaderSize]...), b...)
+	}
+
+	swapped := body()
+	copy(swapped[:sealedSegment], encrypted[streamHeaderSize+sealedSegment:])
+	copy(swapped[sealedSegment:], encrypted[streamHeaderSize:streamHeaderSize+sealedSegment])
+
+	flipped := append([]byte(nil), encrypted...)
+	flipped[len(flipped)-20] ^= 0x01
+
+	header := append([]byte(nil), encrypted...)
+	header[8] ^= 0x01
+
+	tests := []struct {
+		name    string
+		key     [32]byte
+		data    []byte
+		wantErr error
+	}{
+		{
+			name:    "Truncated at segment boundary",
+			key:     key,
+			data:    withBody(body()[:2*sealedSegment]),
+			wantErr: ErrAuthFailed,
+		},
+		{
+			name:    "Truncated inside segment",
+			key:     key,
+			data:    encrypted[:len(encrypted)-5],
+			wantErr: ErrAuthFailed,
+		},
+		{
+			name:    "Reordered segments",
+			key:     key,
+			data:    withBody(swapped),
+			wantErr: ErrAuthFailed,
+		},
+		{
+			name:    "Flipped bit",
+			key:     key,
+			data:    flipped,
+			wantErr: ErrAuthFailed,
+		},
+		{
+			name:    "Modified nonce prefix",
+			key:     key,
+			data:    header,
+			wantErr: ErrAuthFailed,
+		},
+		{
+			name:    "Wrong key",
+			key:     createRandomKey(),
+			data:    encrypted,
+			wantErr: ErrAuthFailed,
+		},
+		{
+			name:    "Missing header",
+			key:     key,
+			data:    encrypted[:5],
+			wantErr: ErrInvalidEnvelope,
+		},
+		{
+			name:    "Legacy ciphertext",
+			key:     key,
+			data:    append(make([]byte, 16), data...),
+			wantErr: ErrInvalidEnvelope,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decryptStream(tt.key, tt.data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("decrypt error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEncryptWriterClosed(t *testing.T) {
+	w, err := NewEncryptWriter(createTestKey(), io.Discard)
+	if err != nil {
+		t.Fatalf("NewEncryptWriter() unexpected error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() unexpected error = %v", err)
+	}
+	if _, err := w.Write([]byte("late")); !errors.Is(err, ErrStreamClosed) {
+		t.Errorf("Write() after Close error = %v, want %v", err, ErrStreamClosed)
+	}
+}
+
+func BenchmarkEncryptWriter(b *testing.B) {
+	key := createTestKey()
+	data := make([]byte, 1024*1024)
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w, _ := NewEncryptWriter(key, io.Discard)
+		w.Write(data)
+		w.Close()
+	}
+}
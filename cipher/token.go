@@ -0,0 +1,93 @@
+package cipher
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrTokenInvalid is returned when a token is malformed or its signature does not
+// match, i.e. it was forged, modified or signed with another key.
+var ErrTokenInvalid = errors.New("invalid token signature")
+
+// ErrTokenExpired is returned for a correctly signed token whose expiry has passed.
+var ErrTokenExpired = errors.New("token expired")
+
+// ErrEmptyKey is returned when signing or verifying with an empty key.
+var ErrEmptyKey = errors.New("empty key")
+
+// ErrNegativeTTL is returned by SignToken for a ttl below zero, typically a deadline
+// that already passed.
+var ErrNegativeTTL = errors.New("negative token ttl")
+
+// NoExpiry is the ttl of tokens that never expire, see SignToken.
+const NoExpiry time.Duration = 0
+
+// timeNow is replaced in tests.
+var timeNow = time.Now
+
+// SignToken returns a compact, URL safe token carrying payload, signed with
+// HMAC-SHA256 under key. It is suitable for signed cookies and download links.
+//
+// The token has the form payload.expiry.signature, payload and signature being
+// base64url encoded without padding and expiry a unix timestamp, rounded up to the
+// second so the token lasts at least ttl. A ttl of NoExpiry
+// produces a token that never expires, and a negative ttl returns ErrNegativeTTL
+// rather than a token. The payload is only signed, not encrypted.
+func SignToken(key, payload []byte, ttl time.Duration) (string, error) {
+	if len(key) == 0 {
+		return "", ErrEmptyKey
+	}
+	if ttl < 0 {
+		return "", ErrNegativeTTL
+	}
+	var expiry int64
+	if ttl > 0 {
+		// Round up to the second, so that a token never expires before its ttl.
+		expires := timeNow().Add(ttl)
+		expiry = expires.Unix()
+		if expires.Nanosecond() > 0 {
+			expiry++
+		}
+	}
+	body := base64.RawURLEncoding.EncodeToString(payload) + "." + strconv.FormatInt(expiry, 10)
+	return body + "." + base64.RawURLEncoding.EncodeToString(HMACSHA256(key, []byte(body))), nil
+}
+
+// VerifyToken checks the signature and expiry of a token produced by SignToken and
+// returns its payload.
+//
+// ErrTokenInvalid is returned for forged or malformed tokens and ErrTokenExpired for
+// genuine tokens past their expiry.
+func VerifyToken(key []byte, token string) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, ErrEmptyKey
+	}
+	i := strings.LastIndexByte(token, '.')
+	if i < 0 {
+		return nil, ErrTokenInvalid
+	}
+	body := token[:i]
+	mac, err := base64.RawURLEncoding.DecodeString(token[i+1:])
+	if err != nil || !VerifyHMACSHA256(key, []byte(body), mac) {
+		return nil, ErrTokenInvalid
+	}
+	encoded, expires, ok := strings.Cut(body, ".")
+	if !ok {
+		return nil, ErrTokenInvalid
+	}
+	expiry, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return nil, ErrTokenInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrTokenInvalid
+	}
+	if expiry != 0 && timeNow().Unix() >= expiry {
+		return nil, ErrTokenExpired
+	}
+	return payload, nil
+}
@@ -0,0 +1,159 @@
+package cipher
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignVerifyToken(t *testing.T) {
+	key := []byte("this-is-a-32-byte-key-for-test!!")
+	payload := []byte(`{"user":42,"file":"report.pdf"}`)
+
+	token, err := SignToken(key, payload, time.Hour)
+	if err != nil {
+		t.Fatalf("SignToken() unexpected error = %v", err)
+	}
+	if strings.ContainsAny(token, "+/=") {
+		t.Errorf("SignToken() = %v, want URL safe token", token)
+	}
+	result, err := VerifyToken(key, token)
+	if err != nil {
+		t.Fatalf("VerifyToken() unexpected error = %v", err)
+	}
+	if !bytes.Equal(result, payload) {
+		t.Errorf("VerifyToken() = %s, want %s", result, payload)
+	}
+}
+
+func TestSignTokenNegativeTTL(t *testing.T) {
+	key := []byte("this-is-a-32-byte-key-for-test!!")
+	deadline := time.Now().Add(-time.Minute)
+
+	token, err := SignToken(key, []byte("payload"), time.Until(deadline))
+	if !errors.Is(err, ErrNegativeTTL) || token != "" {
+		t.Errorf("SignToken() = %q, %v, want no token and %v", token, err, ErrNegativeTTL)
+	}
+}
+
+func TestSignTokenSubSecond(t *testing.T) {
+	key := []byte("this-is-a-32-byte-key-for-test!!")
+	defer func() { timeNow = time.Now }()
+
+	signed := time.Date(2024, 1, 1, 0, 0, 10, 300*int(time.Millisecond), time.UTC)
+	timeNow = func() time.Time { return signed }
+	token, err := SignToken(key, []byte("payload"), 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("SignToken() unexpected error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		now     time.Time
+		wantErr error
+	}{
+		{name: "Right after signing", now: signed, wantErr: nil},
+		{name: "Before ttl elapsed", now: signed.Add(450 * time.Millisecond), wantErr: nil},
+		{name: "After ttl and rounding", now: signed.Add(700 * time.Millisecond), wantErr: ErrTokenExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeNow = func() time.Time { return tt.now }
+			if _, err := VerifyToken(key, token); err != tt.wantErr {
+				t.Errorf("VerifyToken() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyTokenErrors(t *testing.T) {
+	key := []byte("this-is-a-32-byte-key-for-test!!")
+	defer func() { timeNow = time.Now }()
+
+	signed := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return signed }
+	token, _ := SignToken(key, []byte("payload"), time.Minute)
+	forever, _ := SignToken(key, []byte("payload"), NoExpiry)
+
+	parts := strings.Split(token, ".")
+	forgedPayload := Base64Encode("other") + "." + parts[1] + "." + parts[2]
+	extendedExpiry := parts[0] + "." + "9999999999" + "." + parts[2]
+
+	tests := []struct {
+		name    string
+		key     []byte
+		token   string
+		now     time.Time
+		wantErr error
+	}{
+		{
+			name:    "Valid before expiry",
+			key:     key,
+			token:   token,
+			now:     signed.Add(30 * time.Second),
+			wantErr: nil,
+		},
+		{
+			name:    "Expired",
+			key:     key,
+			token:   token,
+			now:     signed.Add(2 * time.Minute),
+			wantErr: ErrTokenExpired,
+		},
+		{
+			name:    "Never expires",
+			key:     key,
+			token:   forever,
+			now:     signed.Add(24 * 365 * time.Hour),
+			wantErr: nil,
+		},
+		{
+			name:    "Wrong key",
+			key:     []byte("another-key"),
+			token:   token,
+			now:     signed,
+			wantErr: ErrTokenInvalid,
+		},
+		{
+			name:    "Forged payload",
+			key:     key,
+			token:   forgedPayload,
+			now:     signed,
+			wantErr: ErrTokenInvalid,
+		},
+		{
+			name:    "Extended expiry",
+			key:     key,
+			token:   extendedExpiry,
+			now:     signed.Add(2 * time.Minute),
+			wantErr: ErrTokenInvalid,
+		},
+		{
+			name:    "Garbage",
+			key:     key,
+			token:   "not-a-token",
+			now:     signed,
+			wantErr: ErrTokenInvalid,
+		},
+		{
+			name:    "Empty key",
+			key:     nil,
+			token:   token,
+			now:     signed,
+			wantErr: ErrEmptyKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeNow = func() time.Time { return tt.now }
+			_, err := VerifyToken(tt.key, tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyToken() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of calling a dependency while its breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerState is the state of a Breaker.
+type BreakerState int
+
+const (
+	// StateClosed lets every call through and watches the failure ratio.
+	StateClosed BreakerState = iota
+	// StateOpen rejects every call with ErrCircuitOpen until the cool-down is over.
+	StateOpen
+	// StateHalfOpen lets a few probe calls through to check whether the dependency
+	// recovered.
+	StateHalfOpen
+)
+
+// String returns the name of the state.
+func (s BreakerState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// breakerBuckets is the number of buckets the rolling window is split into.
+const breakerBuckets = 10
+
+// BreakerConfig takes up the configuration of a Breaker. Fields left to their zero
+// value take a default.
+type BreakerConfig struct {
+	// FailureRatio opens the breaker when reached by the failed calls within Window.
+	// Default 0.5.
+	FailureRatio float64
+	// MinRequests is the number of calls needed within Window before the ratio is
+	// considered, so that a single failure does not open the breaker. Default 10.
+	MinRequests int
+	// Window is the rolling period the failure ratio is computed over. Default 10s.
+	Window time.Duration
+	// CoolDown is how long the breaker stays open before probing. Default 30s.
+	CoolDown time.Duration
+	// HalfOpenRequests is the number of probe calls allowed while half-open. All of them
+	// must succeed to close the breaker, a single failure opens it again. Default 1.
+	HalfOpenRequests int
+	// IsFailure tells whether the error of a call counts as a failure. By default every
+	// error but context.Canceled does. A context.Canceled call that is not a failure
+	// counts neither way: the caller gave up, which says nothing of the dependency.
+	IsFailure func(error) bool
+	// OnStateChange, if set, is called on every state change. It is called from the
+	// goroutine that caused the change, outside of the breaker's lock.
+	OnStateChange func(from, to BreakerState)
+}
+
+// breakerBucket counts the calls of one slice of the rolling window.
+type breakerBucket struct {
+	slot      int64
+	successes int
+	failures  int
+}
+
+// Breaker is a circuit breaker: after too many failures of a dependency it rejects
+// calls right away with ErrCircuitOpen, giving the dependency time to recover instead
+// of hammering it. It is safe for concurrent use.
+//
+// Usage:
+//
+//	breaker := NewBreaker(BreakerConfig{FailureRatio: 0.5, CoolDown: 10 * time.Second})
+//	err := breaker.Execute(func() error { return callPartnerAPI() })
+//	if errors.Is(err, ErrCircuitOpen) {
+//		// serve a fallback
+//	}
+type Breaker struct {
+	config BreakerConfig
+
+	mutex    sync.Mutex
+	state    BreakerState
+	openedAt time.Time
+	buckets  [breakerBuckets]breakerBucket
+	// generation changes with the state, results of calls let through in an older
+	// state are ignored.
+	generation uint64
+	// probes in flight and successful probes while half-open.
+	probes, probeSuccesses int
+	// state changes to notify once the lock is released.
+	changes [][2]BreakerState
+	// now returns the current time, replaced in tests.
+	now func() time.Time
+}
+
+// NewBreaker returns a closed breaker.
+func NewBreaker(config BreakerConfig) *Breaker {
+	if config.FailureRatio <= 0 {
+		config.FailureRatio = 0.5
+	}
+	if config.MinRequests <= 0 {
+		config.MinRequests = 10
+	}
+	if config.Window <= 0 {
+		config.Window = 10 * time.Second
+	}
+	if config.CoolDown <= 0 {
+		config.CoolDown = 30 * time.Second
+	}
+	if config.HalfOpenRequests <= 0 {
+		config.HalfOpenRequests = 1
+	}
+	if config.IsFailure == nil {
+		config.IsFailure = func(err error) bool {
+			return err != nil && !errors.Is(err, context.Canceled)
+		}
+	}
+	return &Breaker{config: config, now: time.Now}
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() BreakerState {
+	b.mutex.Lock()
+	defer b.unlock()
+	b.checkCoolDown(b.now())
+	return b.state
+}
+
+// Execute calls fn if the breaker allows it and records the outcome. It returns
+// ErrCircuitOpen without calling fn if the breaker is open. A panic in fn is recorded
+// as a failure and propagated.
+func (b *Breaker) Execute(fn func() error) (err error) {
+	done, err := b.Allow()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			done(NewPanicError(r))
+			panic(r)
+		}
+	}()
+	err = fn()
+	done(err)
+	return err
+}
+
+// Allow asks the breaker for permission to make a call, for cases where Execute does
+// not fit. If the call is allowed, done must be called exactly once with its outcome.
+// Otherwise the error is ErrCircuitOpen.
+func (b *Breaker) Allow() (done func(err error), err error) {
+	b.mutex.Lock()
+	defer b.unlock()
+	now := b.now()
+	b.checkCoolDown(now)
+	switch b.state {
+	case StateOpen:
+		return nil, ErrCircuitOpen
+	case StateHalfOpen:
+		if b.probes >= b.config.HalfOpenRequests {
+			return nil, ErrCircuitOpen
+		}
+		b.probes++
+	}
+	generation := b.generation
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() { b.record(generation, err) })
+	}, nil
+}
+
+// record accounts for the outcome of a call let through in generation.
+func (b *Breaker) record(generation uint64, err error) {
+	b.mutex.Lock()
+	defer b.unlock()
+	if generation != b.generation {
+		return
+	}
+	now := b.now()
+	failed := b.config.IsFailure(err)
+	if !failed && errors.Is(err, context.Canceled) {
+		// Free the probe slot for another call.
+		if b.state == StateHalfOpen {
+			b.probes--
+		}
+		return
+	}
+	switch b.state {
+	case StateClosed:
+		bucket := b.bucket(now)
+		if failed {
+			bucket.failures++
+		} else {
+			bucket.successes++
+		}
+		successes, failures := b.totals(now)
+		total := successes + failures
+		if total >= b.config.MinRequests && float64(failures)/float64(total) >= b.config.FailureRatio {
+			b.setState(StateOpen, now)
+		}
+	case StateHalfOpen:
+		if failed {
+			b.setState(StateOpen, now)
+			return
+		}
+		b.probeSuccesses++
+		if b.probeSuccesses >= b.config.HalfOpenRequests {
+			b.setState(StateClosed, now)
+		}
+	}
+}
+
+// checkCoolDown moves an open breaker to half-open once the cool-down is over.
+func (b *Breaker) checkCoolDown(now time.Time) {
+	if b.state == StateOpen && now.Sub(b.openedAt) >= b.config.CoolDown {
+		b.setState(StateHalfOpen, now)
+	}
+}
+
+// setState switches to state to and resets the counters. Must be called with mutex held.
+func (b *Breaker) setState(to BreakerState, now time.Time) {
+	from := b.state
+	b.state = to
+	b.generation++
+	b.probes, b.probeSuccesses = 0, 0
+	b.buckets = [breakerBuckets]breakerBucket{}
+	if to == StateOpen {
+		b.openedAt = now
+	}
+	if b.config.OnStateChange != nil {
+		b.changes = append(b.changes, [2]BreakerState{from, to})
+	}
+}
+
+// unlock releases the mutex, then notifies the state changes made while it was held.
+func (b *Breaker) unlock() {
+	changes := b.changes
+	b.changes = nil
+	b.mutex.Unlock()
+	for _, change := range changes {
+		b.config.OnStateChange(change[0], change[1])
+	}
+}
+
+// bucket returns the bucket of the rolling window now falls in.
+func (b *Breaker) bucket(now time.Time) *breakerBucket {
+	slot := b.slot(now)
+	bucket := &b.buckets[slot%breakerBuckets]
+	if bucket.slot != slot {
+		*bucket = breakerBucket{slot: slot}
+	}
+	return bucket
+}
+
+// slot returns the index of the bucket sized slice of time now falls in.
+func (b *Breaker) slot(now time.Time) int64 {
+	return now.UnixNano() / int64(max(b.config.Window/breakerBuckets, 1))
+}
+
+// totals returns the calls recorded within the rolling window ending at now.
+func (b *Breaker) totals(now time.Time) (successes, failures int) {
+	current := b.slot(now)
+	for _, bucket := range b.buckets {
+		if bucket.slot > current-breakerBuckets {
+			successes += bucket.successes
+			failures += bucket.failures
+		}
+	}
+	return
+}
+
+// WithBreaker makes every task of a parallel run go through breaker: while it is open,
+// tasks fail right away with ErrCircuitOpen instead of being called.
+func WithBreaker(breaker *Breaker) Option {
+	return func(c *config) {
+		c.breaker = breaker
+	}
+}
+
+// breakerTask wraps task so that every call goes through breaker.
+func breakerTask[R any](task func(context.Context, int) (R, error), breaker *Breaker) func(context.Context, int) (R, error) {
+	return func(ctx context.Context, offset int) (R, error) {
+		var value R
+		err := breaker.Execute(func() error {
+			var err error
+			value, err = task(ctx, offset)
+			return err
+		})
+		return value, err
+	}
+}
@@ -0,0 +1,158 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// newTestBreaker returns a breaker whose clock only moves when the returned func is
+// called, and the list of state changes it went through.
+func newTestBreaker(config BreakerConfig) (*Breaker, func(time.Duration), *[]string) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	changes := &[]string{}
+	config.OnStateChange = func(from, to BreakerState) {
+		*changes = append(*changes, from.String()+">"+to.String())
+	}
+	b := NewBreaker(config)
+	b.now = func() time.Time { return now }
+	return b, func(d time.Duration) { now = now.Add(d) }, changes
+}
+
+func TestBreaker(t *testing.T) {
+	errDown := errors.New("down")
+	b, advance, changes := newTestBreaker(BreakerConfig{
+		FailureRatio:     0.5,
+		MinRequests:      4,
+		Window:           time.Second,
+		CoolDown:         5 * time.Second,
+		HalfOpenRequests: 2,
+	})
+	call := func(err error) error {
+		return b.Execute(func() error { return err })
+	}
+
+	// 3 calls are not enough to judge, even all failing.
+	call(nil)
+	call(errDown)
+	call(errDown)
+	if b.State() != StateClosed {
+		t.Fatalf("State() = %v, want closed below MinRequests", b.State())
+	}
+	call(errDown)
+	if b.State() != StateOpen {
+		t.Fatalf("State() = %v, want open at 3 failures out of 4", b.State())
+	}
+
+	called := false
+	if err := b.Execute(func() error { called = true; return nil }); err != ErrCircuitOpen || called {
+		t.Errorf("Execute() error = %v, called = %v, want %v without calling", err, called, ErrCircuitOpen)
+	}
+
+	advance(5 * time.Second)
+	if b.State() != StateHalfOpen {
+		t.Fatalf("State() = %v, want half-open after cool-down", b.State())
+	}
+	done1, err1 := b.Allow()
+	done2, err2 := b.Allow()
+	if _, err := b.Allow(); err1 != nil || err2 != nil || err != ErrCircuitOpen {
+		t.Errorf("Allow() while half-open = %v, %v, %v, want 2 probes then %v", err1, err2, err, ErrCircuitOpen)
+	}
+	done1(nil)
+	done1(errDown) // only the first report counts
+	if b.State() != StateHalfOpen {
+		t.Fatalf("State() = %v, want half-open until all probes succeed", b.State())
+	}
+	done2(nil)
+	if b.State() != StateClosed {
+		t.Fatalf("State() = %v, want closed after successful probes", b.State())
+	}
+
+	want := []string{"closed>open", "open>half-open", "half-open>closed"}
+	if len(*changes) != len(want) {
+		t.Fatalf("OnStateChange got %v, want %v", *changes, want)
+	}
+	for k := range want {
+		if (*changes)[k] != want[k] {
+			t.Errorf("OnStateChange got %v, want %v", *changes, want)
+		}
+	}
+}
+
+func TestBreakerHalfOpenFailure(t *testing.T) {
+	errDown := errors.New("down")
+	b, advance, _ := newTestBreaker(BreakerConfig{MinRequests: 1, CoolDown: time.Second})
+
+	b.Execute(func() error { return errDown })
+	advance(time.Second)
+	b.Execute(func() error { return errDown })
+	if b.State() != StateOpen {
+		t.Errorf("State() = %v, want open after a failed probe", b.State())
+	}
+	advance(500 * time.Millisecond)
+	if b.State() != StateOpen {
+		t.Errorf("State() = %v, want a new cool-down after a failed probe", b.State())
+	}
+}
+
+func TestBreakerWindow(t *testing.T) {
+	errDown := errors.New("down")
+	b, advance, _ := newTestBreaker(BreakerConfig{MinRequests: 4, Window: time.Second})
+
+	for i := 0; i < 3; i++ {
+		b.Execute(func() error { return errDown })
+	}
+	// Old failures leave the window.
+	advance(2 * time.Second)
+	b.Execute(func() error { return errDown })
+	if b.State() != StateClosed {
+		t.Errorf("State() = %v, want closed once old failures left the window", b.State())
+	}
+
+	// Canceled calls are not failures by default.
+	for i := 0; i < 6; i++ {
+		b.Execute(func() error { return context.Canceled })
+	}
+	if b.State() != StateClosed {
+		t.Errorf("State() = %v, want canceled calls not to count", b.State())
+	}
+	// They do not count as successes either.
+	for i := 0; i < 3; i++ {
+		b.Execute(func() error { return errDown })
+	}
+	if b.State() != StateOpen {
+		t.Errorf("State() = %v, want open at 4 failures and 6 canceled calls", b.State())
+	}
+}
+
+func TestBreakerHalfOpenCanceled(t *testing.T) {
+	errDown := errors.New("down")
+	b, advance, _ := newTestBreaker(BreakerConfig{MinRequests: 1, CoolDown: time.Second})
+
+	b.Execute(func() error { return errDown })
+	advance(time.Second)
+	b.Execute(func() error { return context.Canceled })
+	if b.State() != StateHalfOpen {
+		t.Fatalf("State() = %v, want half-open after a canceled probe", b.State())
+	}
+	// The canceled probe gave its slot back.
+	if err := b.Execute(func() error { return nil }); err != nil {
+		t.Errorf("Execute() after a canceled probe error = %v, want nil", err)
+	}
+	if b.State() != StateClosed {
+		t.Errorf("State() = %v, want closed after a successful probe", b.State())
+	}
+}
+
+func TestMapWithBreaker(t *testing.T) {
+	errDown := errors.New("down")
+	breaker := NewBreaker(BreakerConfig{MinRequests: 2, CoolDown: time.Hour})
+	fn := func(ctx context.Context, n int) (int, error) { return 0, errDown }
+
+	Map(context.Background(), []int{1, 2}, fn, WithBreaker(breaker), WithCollectAll())
+	_, err := Map(context.Background(), []int{1, 2}, fn, WithBreaker(breaker), WithCollectAll())
+	if !errors.Is(err, ErrCircuitOpen) || errors.Is(err, errDown) {
+		t.Errorf("Map() error = %v, want only %v", err, ErrCircuitOpen)
+	}
+}
@@ -1,9 +1,14 @@
 package concurrency
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestParallelize(t *testing.T) {
@@ -32,6 +37,13 @@ func TestParallelize(t *testing.T) {
 		30, 32, 34, nil, 38, 40, 42, 44, 46, 48,
 	}
 	for k, _ := range result {
+		if k == 3 {
+			//task 3 panics
+			if perr, ok := result[k].(*PanicError); !ok || perr.Value != "p[an" {
+				t.Errorf("Expected *PanicError, Got %v at Index: %d", result[k], k)
+			}
+			continue
+		}
 		if result[k] != expected[k] {
 			t.Errorf("Expected %v, Got %v at Index: %d", expected[k], result[k], k)
 		}
@@ -64,12 +76,256 @@ func TestParallelizeThrottled(t *testing.T) {
 		30, 32, 34, nil, 38, 40, 42, 44, 46, 48,
 	}
 	for k, _ := range result {
+		if k == 3 {
+			//task 3 panics
+			if perr, ok := result[k].(*PanicError); !ok || perr.Value != "p[an" {
+				t.Errorf("Expected *PanicError, Got %v at Index: %d", result[k], k)
+			}
+			continue
+		}
 		if result[k] != expected[k] {
 			t.Errorf("Expected %v, Got %v at Index: %d", expected[k], result[k], k)
 		}
 	}
 }
 
+func TestParallelizeThrottledSlidingWindow(t *testing.T) {
+	const tasks, factor = 12, 3
+	release := make(chan struct{})
+	var finished, running, peak int32
+
+	fss := make([]func() interface{}, tasks)
+	fss[0] = func() interface{} {
+		//slow task, only done once all the others got a slot and finished.
+		select {
+		case <-release:
+			return "released"
+		case <-time.After(2 * time.Second):
+			return "timeout"
+		}
+	}
+	for i := 1; i < tasks; i++ {
+		fss[i] = func(i int) func() interface{} {
+			return func() interface{} {
+				now := atomic.AddInt32(&running, 1)
+				defer atomic.AddInt32(&running, -1)
+				for {
+					p := atomic.LoadInt32(&peak)
+					if now <= p || atomic.CompareAndSwapInt32(&peak, p, now) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				if atomic.AddInt32(&finished, 1) == tasks-1 {
+					close(release)
+				}
+				return i
+			}
+		}(i)
+	}
+
+	result := ParallelizeThrottled(fss, factor)
+	if result[0] != "released" {
+		t.Errorf("Expected other tasks to run while the slow one holds its slot, Got %v", result[0])
+	}
+	for i := 1; i < tasks; i++ {
+		if result[i] != i {
+			t.Errorf("Expected %v, Got %v at Index: %d", i, result[i], i)
+		}
+	}
+	//the slow task holds one slot, others share the rest.
+	if peak > factor-1 {
+		t.Errorf("Expected at most %d tasks besides the slow one, Got %d", factor-1, peak)
+	}
+}
+
+func TestParallelizeCtx(t *testing.T) {
+	errBoom := errors.New("boom")
+	release := make(chan struct{})
+	defer close(release)
+
+	tasks := []func(context.Context) (interface{}, error){
+		func(ctx context.Context) (interface{}, error) { return 1, nil },
+		func(ctx context.Context) (interface{}, error) { return nil, errBoom },
+		func(ctx context.Context) (interface{}, error) {
+			<-ctx.Done() // well behaved, stops on cancellation
+			return nil, ctx.Err()
+		},
+		func(ctx context.Context) (interface{}, error) {
+			<-release // hung call ignoring ctx
+			return 4, nil
+		},
+		func(ctx context.Context) (interface{}, error) { return 5, nil },
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	result, errs := ParallelizeCtx(ctx, tasks)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("ParallelizeCtx() waited %v for a hung task", elapsed)
+	}
+
+	tests := []struct {
+		index   int
+		want    interface{}
+		wantErr error
+	}{
+		{index: 0, want: 1},
+		{index: 1, want: nil, wantErr: errBoom},
+		{index: 2, want: nil, wantErr: context.DeadlineExceeded},
+		{index: 3, want: nil, wantErr: ErrCanceled},
+		{index: 4, want: 5},
+	}
+	for _, tt := range tests {
+		if result[tt.index] != tt.want {
+			t.Errorf("Expected %v, Got %v at Index: %d", tt.want, result[tt.index], tt.index)
+		}
+		if tt.wantErr == nil && errs[tt.index] != nil {
+			t.Errorf("Expected no error, Got %v at Index: %d", errs[tt.index], tt.index)
+		}
+		if tt.wantErr != nil && !errors.Is(errs[tt.index], tt.wantErr) {
+			t.Errorf("Expected error %v, Got %v at Index: %d", tt.wantErr, errs[tt.index], tt.index)
+		}
+	}
+	if !errors.Is(errs[3], context.DeadlineExceeded) {
+		t.Errorf("Expected canceled error to wrap the deadline, Got %v", errs[3])
+	}
+}
+
+func TestParallelizeCtxCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	result, errs := ParallelizeCtx(ctx, []func(context.Context) (interface{}, error){
+		func(ctx context.Context) (interface{}, error) { called = true; return 1, nil },
+	})
+	if called {
+		t.Errorf("Expected no task to start on a canceled context")
+	}
+	if result[0] != nil || !errors.Is(errs[0], ErrCanceled) || !errors.Is(errs[0], context.Canceled) {
+		t.Errorf("Expected canceled result, Got %v, %v", result[0], errs[0])
+	}
+}
+
+func TestParallelizeCtxPanic(t *testing.T) {
+	_, errs := ParallelizeCtx(context.Background(), []func(context.Context) (interface{}, error){
+		func(ctx context.Context) (interface{}, error) { panic(errBoomPanic) },
+	})
+	var perr *PanicError
+	if !errors.As(errs[0], &perr) {
+		t.Fatalf("Expected *PanicError, Got %v", errs[0])
+	}
+	if !errors.Is(errs[0], errBoomPanic) {
+		t.Errorf("Expected PanicError to unwrap to the panic value, Got %v", errs[0])
+	}
+	if len(perr.Stack) == 0 {
+		t.Errorf("Expected PanicError to carry a stack trace")
+	}
+}
+
+func TestSetPanicHook(t *testing.T) {
+	var reported []*PanicError
+	var mu sync.Mutex
+	SetPanicHook(func(p *PanicError) {
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(reported, p)
+	})
+	defer SetPanicHook(DefaultPanicHook)
+
+	Parallelize([]func() interface{}{
+		func() interface{} { return 1 },
+		func() interface{} { panic("first") },
+		func() interface{} { panic("second") },
+	})
+	if len(reported) != 2 {
+		t.Fatalf("Expected 2 reported panics, Got %d", len(reported))
+	}
+
+	SetPanicHook(nil)
+	result := Parallelize([]func() interface{}{func() interface{} { panic("silent") }})
+	if _, ok := result[0].(*PanicError); !ok {
+		t.Errorf("Expected *PanicError with reporting off, Got %v", result[0])
+	}
+	if len(reported) != 2 {
+		t.Errorf("Expected no report with a nil hook, Got %d", len(reported))
+	}
+}
+
+func TestPanicError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     *PanicError
+		want    string
+		wantErr error
+	}{
+		{name: "String value", err: &PanicError{Value: "oops"}, want: "panic: oops"},
+		{name: "Error value", err: &PanicError{Value: errBoomPanic}, want: "panic: boom", wantErr: errBoomPanic},
+		{name: "With context", err: &PanicError{Value: 42, Context: "loading user"}, want: "loading user: panic: 42"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("Error() = %q, want %q", got, tt.want)
+			}
+			if got := tt.err.Unwrap(); got != tt.wantErr {
+				t.Errorf("Unwrap() = %v, want %v", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+var errBoomPanic = errors.New("boom")
+
+// parallelizeBatched is the former ParallelizeThrottled implementation, which runs
+// tasks in batches of factor and waits for the slowest task of every batch. Kept
+// for comparison in BenchmarkParallelizeThrottled.
+func parallelizeBatched(functions []func() interface{}, factor int) []interface{} {
+	resultSet := make([]interface{}, len(functions))
+	for i := 0; i < len(functions); i += factor {
+		end := min(i+factor, len(functions))
+		wg := sync.WaitGroup{}
+		wg.Add(end - i)
+		for current := i; current < end; current++ {
+			go func(f func() interface{}, offset int) {
+				defer wg.Done()
+				resultSet[offset] = f()
+			}(functions[current], current)
+		}
+		wg.Wait()
+	}
+	return resultSet
+}
+
+// BenchmarkParallelizeThrottled compares batched and sliding window throttling on a
+// skewed workload: one task in every four is ten times slower than the rest.
+func BenchmarkParallelizeThrottled(b *testing.B) {
+	fss := make([]func() interface{}, 40)
+	for i := range fss {
+		delay := time.Millisecond
+		if i%4 == 0 {
+			delay = 10 * time.Millisecond
+		}
+		fss[i] = func() interface{} {
+			time.Sleep(delay)
+			return nil
+		}
+	}
+
+	b.Run("Batched", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			parallelizeBatched(fss, 4)
+		}
+	})
+	b.Run("SlidingWindow", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ParallelizeThrottled(fss, 4)
+		}
+	})
+}
+
 func add(a, b int) int {
 	if a == 13 {
 		panic("p[an")
@@ -2,10 +2,7 @@
 package concurrency
 
 import (
-	"fmt"
-	"sync"
-
-	"github.com/go-errors/errors"
+	"context"
 )
 
 // Parallelize executes the given tasks parallelly and returns the result of execution.
@@ -13,32 +10,10 @@ import (
 // can rest assured that the execution order is not altered.
 //
 // It also panic friendly and tries best to deal with any panics occuring due to
-// foreign function calls.
-//
-//
+// foreign function calls. The result of a task that panicked is a *PanicError, and the
+// panic is reported to the hook set with SetPanicHook.
 func Parallelize(functions []func() interface{}) []interface{} {
-	max := len(functions)
-	resultSet := make([]interface{}, max)
-
-	wg := sync.WaitGroup{}
-	wg.Add(max)
-	for k, f := range functions {
-		go func(f func() interface{}, offset int) {
-			defer wg.Done()
-			defer func() {
-				if r := recover(); r != nil {
-					fmt.Println("Attention!!!   Panic Occurred !!!")
-					fmt.Println("Handled Gracefully !!!")
-					fmt.Println(errors.Wrap(r, 2).ErrorStack())
-				}
-			}()
-			resultSet[offset] = f()
-
-		}(f, k)
-
-	}
-	wg.Wait()
-	return resultSet
+	return ParallelizeThrottled(functions, 0)
 }
 
 // ParallelizeThrottled is same as Parallelize with an extra feature to limit number of parallel
@@ -50,50 +25,27 @@ func Parallelize(functions []func() interface{}) []interface{} {
 // A factor of 5 means, it will make atmost 5 parallel calls.
 // A factor of 0 means, it will make all calls in parallel.
 //
+// Tasks are started as slots free up, so a slow task only holds its own slot and never
+// keeps the other slots idle.
+//
 // It also panic friendly and tries best to deal with any panics occuring due to
-// foreign function calls.
+// foreign function calls. The result of a task that panicked is a *PanicError.
 //
 // Ordering of the resultset is maintained. So, developers can rest assured that the
 // ouput order is same as input order.
 //
-func ParallelizeThrottled(functions []func() interface{}, factor int) []interface{} {
-
-	if factor <= 0 {
-		return Parallelize(functions)
-	}
-	max := len(functions)
-	resultSet := make([]interface{}, max)
-
-	var completed int //keep track of completed tasks.
-
-	for i := 0; i < max; i = i + factor {
-		var toProcess int
-		if max-completed >= factor {
-			toProcess = factor
-		} else {
-			toProcess = max - completed
-		}
-		wg := sync.WaitGroup{}
-		wg.Add(toProcess)
-
-		current := i
-		for current < i+toProcess {
-			go func(f func() interface{}, offset int) {
-				defer wg.Done()
-				defer func() {
-					if r := recover(); r != nil {
-						fmt.Println("Attention!!!   Panic Occurred !!!")
-						fmt.Println("Handled Gracefully !!!")
-						fmt.Println(errors.Wrap(r, 2).ErrorStack())
-					}
-				}()
-				resultSet[offset] = f()
-
-			}(functions[current], current)
-			current++
+// Progress of long runs can be followed by passing WithObserver, and WithSummary gives
+// the failures and task durations once the run is over.
+func ParallelizeThrottled(functions []func() interface{}, factor int, opts ...Option) []interface{} {
+	cfg := newConfig(append([]Option{WithLimit(factor), WithCollectAll()}, opts...))
+	resultSet, errorSet, _ := execute(context.Background(), len(functions), func(_ context.Context, i int) (interface{}, error) {
+		return functions[i](), nil
+	}, cfg)
+	//only panics fail, report them in the result slot.
+	for k, err := range errorSet {
+		if err != nil {
+			resultSet[k] = err
 		}
-		wg.Wait()
-		completed = completed + toProcess
 	}
 	return resultSet
 }
@@ -0,0 +1,46 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrCanceled is reported for tasks that did not finish before the context was
+// canceled or its deadline passed. The returned errors also wrap the context's error,
+// so errors.Is(err, context.DeadlineExceeded) can tell a timeout from a cancellation.
+var ErrCanceled = errors.New("task canceled")
+
+// ParallelizeCtx executes the given tasks parallelly, passing each of them ctx, and
+// returns their results and errors. Both slices are in the same order as the tasks.
+//
+// When ctx is canceled or its deadline passes, ParallelizeCtx returns right away without
+// waiting for the tasks still running: their result is nil and their error wraps
+// ErrCanceled. Tasks should watch ctx.Done() and stop early, the ones that don't are
+// left to finish in the background and their result is dropped. A task that panics
+// gets a *PanicError as its error.
+//
+// Every task runs to completion whatever the failures of the others. Pass WithFailFast
+// or WithBestEffort to cancel the remaining tasks on failures, and WithLimit to bound the
+// number of tasks running at once.
+//
+// Usage:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+//	defer cancel()
+//	results, errs := ParallelizeCtx(ctx, []func(context.Context) (interface{}, error){
+//		func(ctx context.Context) (interface{}, error) { return fetchUser(ctx, 1) },
+//		func(ctx context.Context) (interface{}, error) { return fetchUser(ctx, 2) },
+//	})
+func ParallelizeCtx(ctx context.Context, functions []func(context.Context) (interface{}, error), opts ...Option) ([]interface{}, []error) {
+	cfg := newConfig(append([]Option{WithCollectAll()}, opts...))
+	resultSet, errorSet, _ := execute(ctx, len(functions), func(ctx context.Context, i int) (interface{}, error) {
+		return functions[i](ctx)
+	}, cfg)
+	return resultSet, errorSet
+}
+
+// canceled returns an error matching both ErrCanceled and the context error cause.
+func canceled(cause error) error {
+	return fmt.Errorf("%w: %w", ErrCanceled, cause)
+}
@@ -0,0 +1,173 @@
+package concurrency
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// config holds the settings of a parallel run, built from Options.
+type config struct {
+	// limit is the maximum number of tasks running at once, 0 means no limit.
+	limit int
+	// policy decides when failures cancel the run and what error it returns.
+	policy policy
+	// maxFailures is the number of failures tolerated by the best effort policy.
+	maxFailures int
+	// retry, if set, is the policy used to retry failing tasks.
+	retry *RetryPolicy
+	// rateLimiter, if set, paces the start of tasks.
+	rateLimiter *RateLimiter
+	// breaker, if set, guards every call of the tasks.
+	breaker *Breaker
+	// observer, if set, receives the events of every task.
+	observer Observer
+	// summary, if set, is filled with the summary of the run once it is over.
+	summary *Summary
+}
+
+// Option configures a parallel run, see Map and ParallelizeCtx.
+type Option func(*config)
+
+// WithLimit runs at most n tasks at once. New tasks start as soon as a running one
+// finishes. A limit of 0 or less means all tasks run at once, which is the default.
+func WithLimit(n int) Option {
+	return func(c *config) {
+		c.limit = n
+	}
+}
+
+// newConfig returns the default configuration with opts applied.
+func newConfig(opts []Option) *config {
+	c := &config{policy: policyFailFast}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// outcome carries the result of a single task back to execute.
+type outcome[R any] struct {
+	offset   int
+	value    R
+	err      error
+	duration time.Duration
+}
+
+// execute is the engine behind every parallel runner of this package. It calls task
+// for every index in [0, n) according to cfg and returns the results and errors in
+// index order, along with the first error that was reported by a task. Remaining
+// tasks are canceled once failures exceed what cfg's policy tolerates.
+//
+// When ctx is done, execute returns right away: tasks not started or still running are
+// given an error wrapping ErrCanceled and their eventual result is dropped. Results are
+// only ever written by execute itself, so late tasks cannot race with the caller.
+func execute[R any](ctx context.Context, n int, task func(context.Context, int) (R, error), cfg *config) ([]R, []error, *TaskError) {
+	start := time.Now()
+	resultSet := make([]R, n)
+	errorSet := make([]error, n)
+	if cfg.breaker != nil {
+		task = breakerTask(task, cfg.breaker)
+	}
+	// Retry outside of the breaker, every attempt goes through it.
+	if cfg.retry != nil {
+		task = retryTask(task, *cfg.retry)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Buffered, so that tasks finishing after we returned never block.
+	done := make(chan outcome[R], n)
+	// semaphore, holds a token for every running task.
+	var slots chan struct{}
+	if cfg.limit > 0 && cfg.limit < n {
+		slots = make(chan struct{}, cfg.limit)
+	}
+
+	var failures int32
+	launched := 0
+	for ; launched < n && ctx.Err() == nil; launched++ {
+		if slots != nil {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		if cfg.rateLimiter != nil && cfg.rateLimiter.Wait(ctx) != nil {
+			break
+		}
+		go func(offset int) {
+			o := outcome[R]{offset: offset}
+			o.value, o.err, o.duration = observe(cfg.observer, offset, func() (R, error) {
+				return safeCall(ctx, offset, task)
+			})
+			// Report before canceling, so that execute sees this error and not only
+			// the cancellation it causes.
+			done <- o
+			if slots != nil {
+				<-slots
+			}
+			if o.err != nil && cfg.cancels(int(atomic.AddInt32(&failures, 1))) {
+				cancel()
+			}
+		}(launched)
+	}
+
+	finished := make([]bool, n)
+	var durations []time.Duration
+	var firstErr *TaskError
+	record := func(o outcome[R]) {
+		resultSet[o.offset], errorSet[o.offset] = o.value, o.err
+		finished[o.offset] = true
+		durations = append(durations, o.duration)
+		if o.err != nil && firstErr == nil {
+			firstErr = &TaskError{Index: o.offset, Err: o.err}
+		}
+	}
+	for pending := launched; pending > 0; pending-- {
+		select {
+		case o := <-done:
+			record(o)
+			continue
+		case <-ctx.Done():
+		}
+		// Keep whatever completed in the meantime.
+		for drained := false; !drained; {
+			select {
+			case o := <-done:
+				record(o)
+			default:
+				drained = true
+			}
+		}
+		break
+	}
+	if err := ctx.Err(); err != nil {
+		for k := range finished {
+			if !finished[k] {
+				errorSet[k] = canceled(err)
+			}
+		}
+	}
+	if cfg.summary != nil {
+		*cfg.summary = summarize(errorSet, durations, time.Since(start))
+	}
+	return resultSet, errorSet, firstErr
+}
+
+// safeCall calls task and turns a panic into a *PanicError, which is also reported to
+// the panic hook.
+func safeCall[R any](ctx context.Context, offset int, task func(context.Context, int) (R, error)) (value R, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			perr := NewPanicError(r)
+			ReportPanic(perr)
+			err = perr
+		}
+	}()
+	return task(ctx, offset)
+}
@@ -1,6 +1,7 @@
 package concurrency
 
 import (
+	"context"
 	"fmt"
 )
 
@@ -67,3 +68,12 @@ func ExampleParallelizeThrottled_sum() {
 	fmt.Printf("%+v", result)
 	// Output: [30 32 34 36 38 40 42 44 46 48]
 }
+
+func ExampleMap() {
+	// Results are typed, no assertion needed.
+	squares, err := Map(context.Background(), []int{1, 2, 3, 4}, func(ctx context.Context, n int) (int, error) {
+		return n * n, nil
+	}, WithLimit(2))
+	fmt.Println(squares, err)
+	// Output: [1 4 9 16] <nil>
+}
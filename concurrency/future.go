@@ -0,0 +1,174 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// Future holds the result of an asynchronous call, available once the call is done.
+// Futures are returned by Submit and Async, and composed with Then, All, AllSettled,
+// Any and Race.
+type Future[T any] struct {
+	done  chan struct{}
+	once  sync.Once
+	value T
+	err   error
+}
+
+// newFuture returns a pending future, resolved by calling complete.
+func newFuture[T any]() *Future[T] {
+	return &Future[T]{done: make(chan struct{})}
+}
+
+// complete resolves the future. Only the first call has an effect.
+func (f *Future[T]) complete(value T, err error) {
+	f.once.Do(func() {
+		f.value, f.err = value, err
+		close(f.done)
+	})
+}
+
+// Done returns a channel closed once the result is available.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Await waits for the result of the call. If ctx is done first, Await returns ctx's
+// error; the call itself keeps running and the future can be awaited again.
+func (f *Future[T]) Await(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// ErrNoFutures is the error of Any and Race when called without any future.
+var ErrNoFutures = errors.New("no futures given")
+
+// Result is the outcome of a future, as reported by AllSettled.
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+// Async calls fn in a new goroutine and returns a future of its result. A call that
+// panics fails with a *PanicError.
+//
+// Usage:
+//
+//	user := Async(ctx, func(ctx context.Context) (*User, error) { return fetchUser(ctx, id) })
+//	orders := Then(ctx, user, fetchOrders) // fetchOrders(ctx, *User) ([]Order, error)
+//	prefs := Then(ctx, user, fetchPrefs)   // runs in parallel with fetchOrders
+//	o, err := orders.Await(ctx)
+func Async[T any](ctx context.Context, fn func(context.Context) (T, error)) *Future[T] {
+	future := newFuture[T]()
+	go func() {
+		future.complete(safeCall(ctx, 0, func(ctx context.Context, _ int) (T, error) {
+			return fn(ctx)
+		}))
+	}()
+	return future
+}
+
+// Then returns a future of fn applied to the value of f, called as soon as f succeeds.
+// If f fails, or ctx is done before f resolves, the returned future fails with the same
+// error and fn is not called.
+func Then[T, U any](ctx context.Context, f *Future[T], fn func(context.Context, T) (U, error)) *Future[U] {
+	return Async(ctx, func(ctx context.Context) (U, error) {
+		value, err := f.Await(ctx)
+		if err != nil {
+			var zero U
+			return zero, err
+		}
+		return fn(ctx, value)
+	})
+}
+
+// All returns a future of the values of all the futures, in the same order. It fails
+// as soon as one of the futures fails, with that future's error.
+func All[T any](futures ...*Future[T]) *Future[[]T] {
+	all := newFuture[[]T]()
+	values := make([]T, len(futures))
+	pending := int32(len(futures))
+	if pending == 0 {
+		all.complete(values, nil)
+	}
+	for k, f := range futures {
+		go func(offset int, f *Future[T]) {
+			<-f.done
+			if f.err != nil {
+				all.complete(nil, f.err)
+				return
+			}
+			values[offset] = f.value
+			if atomic.AddInt32(&pending, -1) == 0 {
+				all.complete(values, nil)
+			}
+		}(k, f)
+	}
+	return all
+}
+
+// AllSettled returns a future of the outcomes of all the futures, in the same order,
+// resolved once every future is. It never fails.
+func AllSettled[T any](futures ...*Future[T]) *Future[[]Result[T]] {
+	all := newFuture[[]Result[T]]()
+	go func() {
+		results := make([]Result[T], len(futures))
+		for k, f := range futures {
+			<-f.done
+			results[k] = Result[T]{Value: f.value, Err: f.err}
+		}
+		all.complete(results, nil)
+	}()
+	return all
+}
+
+// Any returns a future of the value of the first future to succeed. If all of them
+// fail, it fails with their errors joined, in the same order as the futures.
+func Any[T any](futures ...*Future[T]) *Future[T] {
+	first := newFuture[T]()
+	if len(futures) == 0 {
+		var zero T
+		first.complete(zero, ErrNoFutures)
+	}
+	errorSet := make([]error, len(futures))
+	pending := int32(len(futures))
+	for k, f := range futures {
+		go func(offset int, f *Future[T]) {
+			<-f.done
+			if f.err == nil {
+				first.complete(f.value, nil)
+				return
+			}
+			errorSet[offset] = f.err
+			if atomic.AddInt32(&pending, -1) == 0 {
+				var zero T
+				first.complete(zero, errors.Join(errorSet...))
+			}
+		}(k, f)
+	}
+	return first
+}
+
+// Race returns a future settled like the first of the futures to resolve, whether it
+// succeeded or failed.
+func Race[T any](futures ...*Future[T]) *Future[T] {
+	race := newFuture[T]()
+	if len(futures) == 0 {
+		var zero T
+		race.complete(zero, ErrNoFutures)
+	}
+	for _, f := range futures {
+		go func(f *Future[T]) {
+			<-f.done
+			race.complete(f.value, f.err)
+		}(f)
+	}
+	return race
+}
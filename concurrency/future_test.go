@@ -0,0 +1,152 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// resolveAfter returns a future resolved with value and err after delay.
+func resolveAfter[T any](delay time.Duration, value T, err error) *Future[T] {
+	return Async(context.Background(), func(ctx context.Context) (T, error) {
+		time.Sleep(delay)
+		return value, err
+	})
+}
+
+func TestAsyncThen(t *testing.T) {
+	ctx := context.Background()
+	user := Async(ctx, func(ctx context.Context) (string, error) { return "jane", nil })
+	orders := Then(ctx, user, func(ctx context.Context, name string) (int, error) { return len(name), nil })
+	greeting := Then(ctx, user, func(ctx context.Context, name string) (string, error) { return "hi " + name, nil })
+
+	if got, err := orders.Await(ctx); got != 4 || err != nil {
+		t.Errorf("Then() = %v, %v, want 4", got, err)
+	}
+	if got, err := greeting.Await(ctx); got != "hi jane" || err != nil {
+		t.Errorf("Then() = %v, %v, want hi jane", got, err)
+	}
+
+	errBoom := errors.New("boom")
+	called := false
+	failed := Then(ctx, resolveAfter(0, "", errBoom), func(ctx context.Context, name string) (int, error) {
+		called = true
+		return 0, nil
+	})
+	if _, err := failed.Await(ctx); err != errBoom || called {
+		t.Errorf("Then() error = %v, called = %v, want %v without calling fn", err, called, errBoom)
+	}
+
+	panicked := Async(ctx, func(ctx context.Context) (int, error) { panic(errBoomPanic) })
+	var perr *PanicError
+	if _, err := panicked.Await(ctx); !errors.As(err, &perr) {
+		t.Errorf("Async() error = %v, want *PanicError", err)
+	}
+}
+
+func TestFutureAwaitContext(t *testing.T) {
+	f := resolveAfter(time.Second, 1, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := f.Await(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Await() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestCombinators(t *testing.T) {
+	errBoom := errors.New("boom")
+	errOther := errors.New("other")
+
+	tests := []struct {
+		name    string
+		future  func() *Future[int]
+		want    int
+		wantErr []error
+	}{
+		{
+			name: "Any first success",
+			future: func() *Future[int] {
+				return Any(resolveAfter(0, 0, errBoom), resolveAfter(30*time.Millisecond, 2, nil), resolveAfter(5*time.Millisecond, 3, nil))
+			},
+			want: 3,
+		},
+		{
+			name: "Any all failed",
+			future: func() *Future[int] {
+				return Any(resolveAfter(0, 0, errBoom), resolveAfter(0, 0, errOther))
+			},
+			wantErr: []error{errBoom, errOther},
+		},
+		{
+			name:    "Any none",
+			future:  func() *Future[int] { return Any[int]() },
+			wantErr: []error{ErrNoFutures},
+		},
+		{
+			name: "Race failure first",
+			future: func() *Future[int] {
+				return Race(resolveAfter(30*time.Millisecond, 1, nil), resolveAfter(0, 0, errBoom))
+			},
+			wantErr: []error{errBoom},
+		},
+		{
+			name: "Race success first",
+			future: func() *Future[int] {
+				return Race(resolveAfter(0, 1, nil), resolveAfter(30*time.Millisecond, 0, errBoom))
+			},
+			want: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.future().Await(context.Background())
+			for _, want := range tt.wantErr {
+				if !errors.Is(err, want) {
+					t.Errorf("Await() error = %v, want %v", err, want)
+				}
+			}
+			if tt.wantErr == nil && (err != nil || got != tt.want) {
+				t.Errorf("Await() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAll(t *testing.T) {
+	ctx := context.Background()
+	values, err := All(resolveAfter(20*time.Millisecond, 1, nil), resolveAfter(0, 2, nil), resolveAfter(10*time.Millisecond, 3, nil)).Await(ctx)
+	if err != nil {
+		t.Fatalf("All() unexpected error = %v", err)
+	}
+	for k, want := range []int{1, 2, 3} {
+		if values[k] != want {
+			t.Errorf("Expected %v, Got %v at Index: %d", want, values[k], k)
+		}
+	}
+
+	errBoom := errors.New("boom")
+	start := time.Now()
+	if _, err := All(resolveAfter(time.Second, 1, nil), resolveAfter(0, 0, errBoom)).Await(ctx); err != errBoom {
+		t.Errorf("All() error = %v, want %v", err, errBoom)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("All() waited for the slow future after a failure")
+	}
+
+	if values, err := All[int]().Await(ctx); err != nil || len(values) != 0 {
+		t.Errorf("All() of no futures = %v, %v", values, err)
+	}
+}
+
+func TestAllSettled(t *testing.T) {
+	errBoom := errors.New("boom")
+	results, err := AllSettled(resolveAfter(10*time.Millisecond, 1, nil), resolveAfter(0, 0, errBoom)).Await(context.Background())
+	if err != nil {
+		t.Fatalf("AllSettled() unexpected error = %v", err)
+	}
+	if results[0].Value != 1 || results[0].Err != nil || results[1].Err != errBoom {
+		t.Errorf("AllSettled() = %+v", results)
+	}
+}
@@ -0,0 +1,45 @@
+package concurrency
+
+import (
+	"context"
+)
+
+// Map calls fn for every item in parallel and returns the results in the same order as
+// items, without any type assertion on the caller side.
+//
+// By default Map fails fast: on the first error the context passed to the other calls
+// is canceled and Map returns a *TaskError wrapping that error right away. Use
+// WithCollectAll or WithBestEffort to choose another policy, and WithLimit to bound the
+// number of calls running at once. Calls that failed or did not finish are given the
+// zero value of R.
+//
+// A call that panics fails with a *PanicError.
+//
+// Usage:
+//
+//	users, err := Map(ctx, ids, func(ctx context.Context, id int) (*User, error) {
+//		return fetchUser(ctx, id)
+//	}, WithLimit(10))
+func Map[T, R any](ctx context.Context, items []T, fn func(context.Context, T) (R, error), opts ...Option) ([]R, error) {
+	cfg := newConfig(opts)
+	resultSet, errorSet, firstErr := execute(ctx, len(items), func(ctx context.Context, i int) (R, error) {
+		return fn(ctx, items[i])
+	}, cfg)
+	return resultSet, cfg.err(errorSet, firstErr)
+}
+
+// Run executes the given tasks in parallel and returns their results in the same order
+// as the tasks. It fails fast like Map, tasks still running when an error is returned
+// are left to finish in the background.
+//
+// Usage:
+//
+//	counts, err := Run(
+//		func() (int, error) { return countUsers() },
+//		func() (int, error) { return countOrders() },
+//	)
+func Run[R any](tasks ...func() (R, error)) ([]R, error) {
+	return Map(context.Background(), tasks, func(_ context.Context, task func() (R, error)) (R, error) {
+		return task()
+	})
+}
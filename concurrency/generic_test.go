@@ -0,0 +1,138 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMap(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5, 6, 7, 8}
+	var running, peak int32
+
+	result, err := Map(context.Background(), items, func(ctx context.Context, n int) (string, error) {
+		now := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if now <= p || atomic.CompareAndSwapInt32(&peak, p, now) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		return strconv.Itoa(n * n), nil
+	}, WithLimit(3))
+	if err != nil {
+		t.Fatalf("Map() unexpected error = %v", err)
+	}
+	expected := []string{"1", "4", "9", "16", "25", "36", "49", "64"}
+	for k := range expected {
+		if result[k] != expected[k] {
+			t.Errorf("Expected %v, Got %v at Index: %d", expected[k], result[k], k)
+		}
+	}
+	if peak > 3 {
+		t.Errorf("Expected at most 3 calls at once, Got %d", peak)
+	}
+}
+
+func TestMapFailFast(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	result, err := Map(context.Background(), []int{0, 1, 2, 3}, func(ctx context.Context, n int) (int, error) {
+		if n == 1 {
+			return 0, errBoom
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(2 * time.Second):
+			return n, nil
+		}
+	})
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("Map() error = %v, want %v", err, errBoom)
+	}
+	if len(result) != 4 {
+		t.Fatalf("Expected 4 results, Got %d", len(result))
+	}
+	for k, v := range result {
+		if v != 0 {
+			t.Errorf("Expected zero value, Got %v at Index: %d", v, k)
+		}
+	}
+}
+
+func TestMapErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     func() (context.Context, context.CancelFunc)
+		fn      func(context.Context, int) (int, error)
+		wantErr error
+	}{
+		{
+			name: "Canceled context",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx, cancel
+			},
+			fn:      func(ctx context.Context, n int) (int, error) { return n, nil },
+			wantErr: ErrCanceled,
+		},
+		{
+			name: "Deadline",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 10*time.Millisecond)
+			},
+			fn: func(ctx context.Context, n int) (int, error) {
+				time.Sleep(time.Second)
+				return n, nil
+			},
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name: "Panic",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			fn:      func(ctx context.Context, n int) (int, error) { panic(errBoomPanic) },
+			wantErr: errBoomPanic,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := tt.ctx()
+			defer cancel()
+			_, err := Map(ctx, []int{1, 2}, tt.fn)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Map() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	result, err := Run(
+		func() (int, error) { return 1, nil },
+		func() (int, error) { return 2, nil },
+		func() (int, error) { return 3, nil },
+	)
+	if err != nil {
+		t.Fatalf("Run() unexpected error = %v", err)
+	}
+	for k, want := range []int{1, 2, 3} {
+		if result[k] != want {
+			t.Errorf("Expected %v, Got %v at Index: %d", want, result[k], k)
+		}
+	}
+
+	errBoom := errors.New("boom")
+	if _, err := Run(func() (int, error) { return 0, errBoom }); !errors.Is(err, errBoom) {
+		t.Errorf("Run() error = %v, want %v", err, errBoom)
+	}
+}
@@ -0,0 +1,91 @@
+package concurrency
+
+import (
+	"context"
+	"sync"
+)
+
+// Group coalesces concurrent calls for the same key: while a call for a key is in
+// flight, other callers asking for that key wait for it and share its result instead
+// of making their own call. The zero value is ready to use.
+//
+// Usage:
+//
+//	var users Group[int, *User]
+//	user, err, shared := users.Do(id, func() (*User, error) {
+//		return fetchUser(id) // at most one query per id at a time
+//	})
+type Group[K comparable, V any] struct {
+	mutex sync.Mutex
+	calls map[K]*flight[V]
+}
+
+// flight is a call in progress, or completed, for a key of a Group.
+type flight[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+	// dups is the number of callers waiting for the call besides the first one.
+	dups int
+}
+
+// GroupResult is the result of a call made with Group.DoChan.
+type GroupResult[V any] struct {
+	Value  V
+	Err    error
+	Shared bool
+}
+
+// Do calls fn and returns its result, unless a call for key is already in flight, in
+// which case it waits for that call and returns its result instead. shared is true if
+// the result was given to more than one caller. A panic of fn is returned to every
+// caller as a *PanicError.
+func (g *Group[K, V]) Do(key K, fn func() (V, error)) (value V, err error, shared bool) {
+	g.mutex.Lock()
+	if g.calls == nil {
+		g.calls = make(map[K]*flight[V])
+	}
+	if f, ok := g.calls[key]; ok {
+		f.dups++
+		g.mutex.Unlock()
+		<-f.done
+		return f.value, f.err, true
+	}
+	f := &flight[V]{done: make(chan struct{})}
+	g.calls[key] = f
+	g.mutex.Unlock()
+
+	f.value, f.err = safeCall(context.Background(), 0, func(context.Context, int) (V, error) {
+		return fn()
+	})
+
+	g.mutex.Lock()
+	// The key may have been forgotten, and a new call started for it meanwhile.
+	if g.calls[key] == f {
+		delete(g.calls, key)
+	}
+	shared = f.dups > 0
+	g.mutex.Unlock()
+	close(f.done)
+	return f.value, f.err, shared
+}
+
+// DoChan is same as Do, but returns a channel receiving the result once ready instead
+// of blocking.
+func (g *Group[K, V]) DoChan(key K, fn func() (V, error)) <-chan GroupResult[V] {
+	ch := make(chan GroupResult[V], 1)
+	go func() {
+		value, err, shared := g.Do(key, fn)
+		ch <- GroupResult[V]{Value: value, Err: err, Shared: shared}
+	}()
+	return ch
+}
+
+// Forget makes the next call for key start a new call instead of waiting for the one
+// in flight, e.g. after the underlying data changed. Callers already waiting still
+// get the result of the call in flight.
+func (g *Group[K, V]) Forget(key K) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	delete(g.calls, key)
+}
@@ -0,0 +1,94 @@
+package concurrency
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroupDo(t *testing.T) {
+	var g Group[string, int]
+	var calls int32
+	release := make(chan struct{})
+
+	const callers = 10
+	var wg sync.WaitGroup
+	values := make([]int, callers)
+	shared := make([]bool, callers)
+	wg.Add(callers)
+	for i := 0; i < callers; i++ {
+		go func(i int) {
+			defer wg.Done()
+			values[i], _, shared[i] = g.Do("user:1", func() (int, error) {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				return 42, nil
+			})
+		}(i)
+	}
+	// Let every caller join the call in flight.
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("Do() called fn %d times, want 1", calls)
+	}
+	for i := 0; i < callers; i++ {
+		if values[i] != 42 || !shared[i] {
+			t.Errorf("Do() = %v, shared %v for caller %d, want 42, true", values[i], shared[i], i)
+		}
+	}
+
+	// Calls in sequence are not coalesced.
+	value, err, isShared := g.Do("user:1", func() (int, error) { return 7, nil })
+	if value != 7 || err != nil || isShared {
+		t.Errorf("Do() = %v, %v, %v, want 7, nil, false", value, err, isShared)
+	}
+}
+
+func TestGroupErrors(t *testing.T) {
+	var g Group[int, string]
+	errBoom := errors.New("boom")
+
+	tests := []struct {
+		name    string
+		fn      func() (string, error)
+		wantErr error
+	}{
+		{name: "Error", fn: func() (string, error) { return "", errBoom }, wantErr: errBoom},
+		{name: "Panic", fn: func() (string, error) { panic(errBoomPanic) }, wantErr: errBoomPanic},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err, _ := g.Do(1, tt.fn); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Do() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGroupForgetAndDoChan(t *testing.T) {
+	var g Group[string, int]
+	release := make(chan struct{})
+	first := g.DoChan("key", func() (int, error) {
+		<-release
+		return 1, nil
+	})
+	time.Sleep(10 * time.Millisecond)
+
+	g.Forget("key")
+	value, _, shared := g.Do("key", func() (int, error) { return 2, nil })
+	if value != 2 || shared {
+		t.Errorf("Do() after Forget = %v, shared %v, want a new call returning 2", value, shared)
+	}
+
+	close(release)
+	result := <-first
+	if result.Value != 1 || result.Err != nil || result.Shared {
+		t.Errorf("DoChan() = %+v, want 1 not shared", result)
+	}
+}
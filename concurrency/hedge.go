@@ -0,0 +1,115 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNoTasks is the error of FirstSuccess when called without any function.
+var ErrNoTasks = errors.New("no tasks given")
+
+// Hedge calls fn, and calls it again if no call succeeded after delay, up to
+// maxAttempts calls in total, which cuts the tail latency of reads against replicas.
+// It returns the result of the first call that succeeds and cancels the others. A
+// failed call starts the next one right away instead of waiting for the delay, and a
+// delay of 0 or less starts all the calls at once.
+//
+// If every call fails, the returned error joins all their errors. If ctx is done first,
+// the error wraps ctx's error along with the errors of the calls that failed meanwhile.
+// fn should watch its ctx, as calls still running are canceled but not waited for.
+//
+// Usage:
+//
+//	// a second replica is tried if the first has not answered within 50ms
+//	user, err := Hedge(ctx, func(ctx context.Context) (*User, error) {
+//		return fetchUserFromReplica(ctx, id)
+//	}, 50*time.Millisecond, 2)
+func Hedge[R any](ctx context.Context, fn func(context.Context) (R, error), delay time.Duration, maxAttempts int) (R, error) {
+	return hedge(ctx, max(maxAttempts, 1), max(delay, 0), func(ctx context.Context, _ int) (R, error) {
+		return fn(ctx)
+	})
+}
+
+// FirstSuccess calls every function of fns at once, and returns the result of the first
+// one that succeeds, canceling the others. Errors are reported as for Hedge.
+//
+// Usage:
+//
+//	rate, err := FirstSuccess(ctx, rateFromProviderA, rateFromProviderB)
+func FirstSuccess[R any](ctx context.Context, fns ...func(context.Context) (R, error)) (R, error) {
+	if len(fns) == 0 {
+		var zero R
+		return zero, ErrNoTasks
+	}
+	return hedge(ctx, len(fns), 0, func(ctx context.Context, i int) (R, error) {
+		return fns[i](ctx)
+	})
+}
+
+// hedge calls task with indexes [0, attempts), starting a new call every delay while no
+// call succeeded, or all at once if delay is 0. It returns the first success.
+func hedge[R any](ctx context.Context, attempts int, delay time.Duration, task func(context.Context, int) (R, error)) (R, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Buffered, so that calls finishing after we returned never block.
+	done := make(chan outcome[R], attempts)
+	launched := 0
+	var timer *time.Timer
+	var next <-chan time.Time
+	launch := func() {
+		go func(offset int) {
+			o := outcome[R]{offset: offset}
+			o.value, o.err = safeCall(ctx, offset, task)
+			done <- o
+		}(launched)
+		launched++
+		if timer != nil {
+			timer.Stop()
+			timer, next = nil, nil
+		}
+		if delay > 0 && launched < attempts {
+			timer = time.NewTimer(delay)
+			next = timer.C
+		}
+	}
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	launch()
+	for delay == 0 && launched < attempts {
+		launch()
+	}
+	errorSet := make([]error, attempts)
+	failures := 0
+	for {
+		select {
+		case o := <-done:
+			if o.err == nil {
+				return o.value, nil
+			}
+			errorSet[o.offset] = o.err
+			failures++
+			if failures == attempts {
+				var zero R
+				return zero, errors.Join(errorSet...)
+			}
+			if launched < attempts {
+				launch()
+			}
+		case <-next:
+			launch()
+		case <-ctx.Done():
+			var zero R
+			if failures == 0 {
+				return zero, ctx.Err()
+			}
+			return zero, fmt.Errorf("%w: %w", ctx.Err(), errors.Join(errorSet...))
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHedge(t *testing.T) {
+	errDown := errors.New("down")
+
+	tests := []struct {
+		name        string
+		maxAttempts int
+		// replies are the delay and error of each successive call.
+		replies   []time.Duration
+		errs      []error
+		wantCalls int32
+		wantValue int
+		wantErr   error
+	}{
+		{
+			name:        "First call fast",
+			maxAttempts: 3,
+			replies:     []time.Duration{0},
+			wantCalls:   1,
+			wantValue:   1,
+		},
+		{
+			name:        "Hedged call wins",
+			maxAttempts: 3,
+			replies:     []time.Duration{time.Second, 0},
+			wantCalls:   2,
+			wantValue:   2,
+		},
+		{
+			name:        "Failure starts next call",
+			maxAttempts: 2,
+			replies:     []time.Duration{0, 0},
+			errs:        []error{errDown, nil},
+			wantCalls:   2,
+			wantValue:   2,
+		},
+		{
+			name:        "All calls fail",
+			maxAttempts: 2,
+			replies:     []time.Duration{0, 0},
+			errs:        []error{errDown, errBoomPanic},
+			wantCalls:   2,
+			wantErr:     errDown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			value, err := Hedge(context.Background(), func(ctx context.Context) (int, error) {
+				call := int(atomic.AddInt32(&calls, 1))
+				select {
+				case <-time.After(tt.replies[call-1]):
+				case <-ctx.Done():
+					return 0, ctx.Err()
+				}
+				if call <= len(tt.errs) && tt.errs[call-1] != nil {
+					return 0, tt.errs[call-1]
+				}
+				return call, nil
+			}, 20*time.Millisecond, tt.maxAttempts)
+
+			if !errors.Is(err, tt.wantErr) || value != tt.wantValue {
+				t.Errorf("Hedge() = %v, %v, want %v, %v", value, err, tt.wantValue, tt.wantErr)
+			}
+			if got := atomic.LoadInt32(&calls); got != tt.wantCalls {
+				t.Errorf("Hedge() made %d calls, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestHedgeContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	_, err := Hedge(ctx, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		time.Sleep(time.Second) // ignores ctx, not waited for
+		return 0, nil
+	}, 10*time.Millisecond, 2)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Hedge() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestFirstSuccess(t *testing.T) {
+	errDown := errors.New("down")
+	var canceled int32
+	slow := func(ctx context.Context) (string, error) {
+		select {
+		case <-time.After(time.Second):
+			return "slow", nil
+		case <-ctx.Done():
+			atomic.AddInt32(&canceled, 1)
+			return "", ctx.Err()
+		}
+	}
+	failing := func(ctx context.Context) (string, error) { return "", errDown }
+	fast := func(ctx context.Context) (string, error) {
+		time.Sleep(10 * time.Millisecond)
+		return "fast", nil
+	}
+
+	value, err := FirstSuccess(context.Background(), slow, failing, fast)
+	if value != "fast" || err != nil {
+		t.Errorf("FirstSuccess() = %v, %v, want fast, nil", value, err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if atomic.LoadInt32(&canceled) != 1 {
+		t.Errorf("FirstSuccess() did not cancel the slow call")
+	}
+
+	if _, err := FirstSuccess(context.Background(), failing, failing); !errors.Is(err, errDown) {
+		t.Errorf("FirstSuccess() error = %v, want %v", err, errDown)
+	}
+	if _, err := FirstSuccess[string](context.Background()); err != ErrNoTasks {
+		t.Errorf("FirstSuccess() error = %v, want %v", err, ErrNoTasks)
+	}
+}
@@ -0,0 +1,104 @@
+package concurrency
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// Observer receives the events of the tasks of a parallel run, e.g. to log the progress
+// of a long bulk job. Tasks run in parallel, so its methods are called concurrently and
+// must be safe for concurrent use. They are called from the goroutine of the task and
+// should return quickly.
+type Observer interface {
+	// TaskStarted is called right before the task at index starts.
+	TaskStarted(index int)
+	// TaskFinished is called once the task at index returned, err being its error.
+	TaskFinished(index int, duration time.Duration, err error)
+	// TaskPanicked is called instead of TaskFinished if the task at index panicked.
+	TaskPanicked(index int, duration time.Duration, perr *PanicError)
+}
+
+// Summary describes a parallel run once it is over, see WithSummary. Durations are
+// those of the tasks that finished, tasks canceled before finishing are only counted in
+// Tasks, Canceled and Failures.
+type Summary struct {
+	// Tasks is the number of tasks of the run.
+	Tasks int
+	// Failures is the number of tasks that returned an error, panicked or were canceled.
+	Failures int
+	// Panics is the number of tasks that panicked.
+	Panics int
+	// Canceled is the number of tasks canceled before they finished.
+	Canceled int
+	// Elapsed is the duration of the whole run.
+	Elapsed time.Duration
+	// P50, P95 and Max are percentiles of the duration of the finished tasks.
+	P50, P95, Max time.Duration
+}
+
+// WithObserver sends the events of every task of the run to obs.
+func WithObserver(obs Observer) Option {
+	return func(c *config) {
+		c.observer = obs
+	}
+}
+
+// WithSummary fills summary once the run is over. summary must not be read before
+// the runner returned.
+func WithSummary(summary *Summary) Option {
+	return func(c *config) {
+		c.summary = summary
+	}
+}
+
+// observe calls task and reports it to obs, if any. It returns the duration of the call.
+func observe[R any](obs Observer, offset int, call func() (R, error)) (value R, err error, duration time.Duration) {
+	if obs != nil {
+		obs.TaskStarted(offset)
+	}
+	start := time.Now()
+	value, err = call()
+	duration = time.Since(start)
+	if obs != nil {
+		var perr *PanicError
+		if errors.As(err, &perr) {
+			obs.TaskPanicked(offset, duration, perr)
+		} else {
+			obs.TaskFinished(offset, duration, err)
+		}
+	}
+	return value, err, duration
+}
+
+// summarize returns the summary of a run from its errors and the durations of the tasks
+// that finished.
+func summarize(errorSet []error, durations []time.Duration, elapsed time.Duration) Summary {
+	s := Summary{Tasks: len(errorSet), Elapsed: elapsed}
+	for _, err := range errorSet {
+		if err == nil {
+			continue
+		}
+		s.Failures++
+		var perr *PanicError
+		switch {
+		case errors.As(err, &perr):
+			s.Panics++
+		case errors.Is(err, ErrCanceled):
+			s.Canceled++
+		}
+	}
+	if len(durations) > 0 {
+		slices.Sort(durations)
+		s.P50 = percentile(durations, 50)
+		s.P95 = percentile(durations, 95)
+		s.Max = durations[len(durations)-1]
+	}
+	return s
+}
+
+// percentile returns the p-th percentile of sorted, using the nearest rank method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
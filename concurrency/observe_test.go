@@ -0,0 +1,122 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder is an Observer keeping count of the events it got.
+type recorder struct {
+	mutex    sync.Mutex
+	started  int
+	finished int
+	failed   int
+	panicked []int
+}
+
+func (r *recorder) TaskStarted(index int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.started++
+}
+
+func (r *recorder) TaskFinished(index int, duration time.Duration, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.finished++
+	if err != nil {
+		r.failed++
+	}
+}
+
+func (r *recorder) TaskPanicked(index int, duration time.Duration, perr *PanicError) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.panicked = append(r.panicked, index)
+}
+
+func TestMapWithObserver(t *testing.T) {
+	errBoom := errors.New("boom")
+	items := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	fn := func(ctx context.Context, n int) (int, error) {
+		switch n {
+		case 3:
+			return 0, errBoom
+		case 7:
+			panic(errBoomPanic)
+		}
+		time.Sleep(time.Duration(n) * time.Millisecond)
+		return n, nil
+	}
+
+	obs := &recorder{}
+	var summary Summary
+	Map(context.Background(), items, fn, WithLimit(4), WithCollectAll(), WithObserver(obs), WithSummary(&summary))
+
+	if obs.started != 10 || obs.finished != 9 || obs.failed != 1 {
+		t.Errorf("Observer got %d started, %d finished, %d failed, want 10, 9, 1", obs.started, obs.finished, obs.failed)
+	}
+	if len(obs.panicked) != 1 || obs.panicked[0] != 6 {
+		t.Errorf("Observer got panics at %v, want [6]", obs.panicked)
+	}
+	if summary.Tasks != 10 || summary.Failures != 2 || summary.Panics != 1 || summary.Canceled != 0 {
+		t.Errorf("Summary = %+v, want 10 tasks, 2 failures, 1 panic", summary)
+	}
+	if summary.Max < 10*time.Millisecond || summary.P50 > summary.P95 || summary.P95 > summary.Max {
+		t.Errorf("Summary durations P50 %v, P95 %v, Max %v out of order", summary.P50, summary.P95, summary.Max)
+	}
+}
+
+func TestParallelizeThrottledWithSummary(t *testing.T) {
+	functions := []func() interface{}{
+		func() interface{} { return 1 },
+		func() interface{} { panic(errBoomPanic) },
+		func() interface{} { return 3 },
+	}
+	var summary Summary
+	results := ParallelizeThrottled(functions, 2, WithSummary(&summary))
+	if len(results) != 3 || results[2] != 3 {
+		t.Errorf("ParallelizeThrottled() = %v, want every result", results)
+	}
+	if summary.Tasks != 3 || summary.Failures != 1 || summary.Panics != 1 {
+		t.Errorf("Summary = %+v, want 3 tasks, 1 panic", summary)
+	}
+}
+
+func TestSummaryCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	var summary Summary
+	Map(ctx, []int{1, 2}, func(ctx context.Context, n int) (int, error) {
+		if n == 2 {
+			time.Sleep(time.Second)
+		}
+		return n, nil
+	}, WithSummary(&summary))
+	if summary.Canceled != 1 || summary.Failures != 1 {
+		t.Errorf("Summary = %+v, want 1 canceled task", summary)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	durations := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	tests := []struct {
+		p    int
+		want time.Duration
+	}{
+		{p: 0, want: 1},
+		{p: 50, want: 5},
+		{p: 95, want: 10},
+		{p: 100, want: 10},
+	}
+
+	for _, tt := range tests {
+		if got := percentile(durations, tt.p); got != tt.want {
+			t.Errorf("percentile(%d) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package concurrency
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	goerrors "github.com/go-errors/errors"
+)
+
+// PanicError is returned in place of the result of a task that panicked, so that a
+// panic can be told apart from a task that legitimately returned nil.
+//
+// Parallelize and ParallelizeThrottled put it in the task's result slot, ParallelizeCtx
+// in the task's error slot:
+//
+//	result := Parallelize(tasks)
+//	if perr, ok := result[3].(*PanicError); ok {
+//		log.Printf("task 3 failed: %v\n%s", perr, perr.Stack)
+//	}
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value interface{}
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+	// Context optionally describes where the panic was recovered.
+	Context string
+}
+
+// NewPanicError builds a PanicError from a value returned by recover. It must be called
+// directly from the deferred function, so that the stack trace starts at the panic.
+//
+//	defer func() {
+//		if r := recover(); r != nil {
+//			err = NewPanicError(r)
+//		}
+//	}()
+func NewPanicError(value interface{}) *PanicError {
+	// skip NewPanicError, the deferred function and the runtime's panic frame.
+	return &PanicError{Value: value, Stack: goerrors.Wrap(value, 3).Stack()}
+}
+
+// Error implements the error interface.
+func (p *PanicError) Error() string {
+	if p.Context != "" {
+		return fmt.Sprintf("%s: panic: %v", p.Context, p.Value)
+	}
+	return fmt.Sprintf("panic: %v", p.Value)
+}
+
+// Unwrap returns the panic value if it is an error, so that errors.Is and errors.As
+// see through the PanicError.
+func (p *PanicError) Unwrap() error {
+	if err, ok := p.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// PanicHook receives every panic recovered by this package, e.g. to send it to an error
+// tracker. It is called from the goroutine that panicked.
+type PanicHook func(p *PanicError)
+
+// DefaultPanicHook writes the panic and its stack trace using the standard logger.
+func DefaultPanicHook(p *PanicError) {
+	log.Printf("Attention!!!   Panic Occurred !!!\n%s\n%s", p.Error(), p.Stack)
+}
+
+var (
+	panicHook      PanicHook = DefaultPanicHook
+	panicHookMutex sync.RWMutex
+)
+
+// SetPanicHook replaces the hook used to report recovered panics. A nil hook turns
+// reporting off; panics are still returned to the caller.
+func SetPanicHook(hook PanicHook) {
+	panicHookMutex.Lock()
+	defer panicHookMutex.Unlock()
+	panicHook = hook
+}
+
+// ReportPanic passes p to the current panic hook.
+func ReportPanic(p *PanicError) {
+	panicHookMutex.RLock()
+	hook := panicHook
+	panicHookMutex.RUnlock()
+	if hook != nil {
+		hook(p)
+	}
+}
@@ -0,0 +1,312 @@
+package concurrency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Pipeline streams data through stages connected by channels, each stage running its
+// own bounded set of goroutines. Unbuffered channels give backpressure: a slow stage
+// slows down the ones before it instead of piling data up in memory.
+//
+// The first error, or panic, of any stage cancels the whole pipeline and is returned
+// by Sink, which also waits for every goroutine of the pipeline to exit.
+//
+// Usage:
+//
+//	p := NewPipeline(ctx)
+//	rows := Source(p, func(ctx context.Context, emit func(Row) error) error {
+//		for rows.Next() {
+//			var row Row
+//			if err := rows.Scan(&row.ID, &row.Data); err != nil {
+//				return err
+//			}
+//			if err := emit(row); err != nil {
+//				return err
+//			}
+//		}
+//		return rows.Err()
+//	})
+//	records := Stage(rows, 8, transform, Unordered)
+//	err := Sink(Batch(records, 500, time.Second), writeBatch)
+type Pipeline struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	once   sync.Once
+	err    error
+}
+
+// Stream is the output of a pipeline stage, to be fed to the next one.
+type Stream[T any] struct {
+	p  *Pipeline
+	ch chan T
+}
+
+// StageMode tells whether a stage keeps the order of its input.
+type StageMode int
+
+const (
+	// Unordered emits results as soon as they are ready.
+	Unordered StageMode = iota
+	// Ordered emits results in the same order as the input. A slow item holds back
+	// the ones after it, up to the number of workers.
+	Ordered
+)
+
+// NewPipeline returns an empty pipeline, canceled along with ctx.
+func NewPipeline(ctx context.Context) *Pipeline {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Pipeline{ctx: ctx, cancel: cancel}
+}
+
+// fail records the first error of the pipeline and cancels it.
+func (p *Pipeline) fail(err error) {
+	p.once.Do(func() {
+		p.err = err
+		p.cancel()
+	})
+}
+
+// spawn runs fn in a new goroutine of the pipeline. An error or a panic of fn fails
+// the pipeline.
+func (p *Pipeline) spawn(fn func(ctx context.Context) error) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		if _, err := safeCall(p.ctx, 0, func(ctx context.Context, _ int) (struct{}, error) {
+			return struct{}{}, fn(ctx)
+		}); err != nil {
+			p.fail(err)
+		}
+	}()
+}
+
+// send writes value to ch, unless ctx is done first. It reports whether value was sent.
+func send[T any](ctx context.Context, ch chan<- T, value T) bool {
+	select {
+	case ch <- value:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// receive reads from ch, unless ctx is done first. Second return parameter is false
+// once ch is closed or ctx is done.
+func receive[T any](ctx context.Context, ch <-chan T) (T, bool) {
+	select {
+	case value, ok := <-ch:
+		return value, ok
+	case <-ctx.Done():
+		var zero T
+		return zero, false
+	}
+}
+
+// Source starts a stream with the values passed to emit by generate. emit blocks while
+// the next stage is busy, and returns an error once the pipeline is canceled, which
+// generate should return right away.
+func Source[T any](p *Pipeline, generate func(ctx context.Context, emit func(T) error) error) *Stream[T] {
+	out := make(chan T)
+	p.spawn(func(ctx context.Context) error {
+		defer close(out)
+		return generate(ctx, func(value T) error {
+			if !send(ctx, out, value) {
+				return ctx.Err()
+			}
+			return nil
+		})
+	})
+	return &Stream[T]{p: p, ch: out}
+}
+
+// FromSlice starts a stream with the items of a slice.
+func FromSlice[T any](p *Pipeline, items []T) *Stream[T] {
+	return Source(p, func(ctx context.Context, emit func(T) error) error {
+		for _, item := range items {
+			if err := emit(item); err != nil {
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+// Stage applies fn to every value of in with the given number of workers, and returns
+// the stream of results. An error of fn fails the pipeline.
+func Stage[T, R any](in *Stream[T], workers int, fn func(context.Context, T) (R, error), mode StageMode) *Stream[R] {
+	if workers < 1 {
+		workers = 1
+	}
+	if mode == Ordered {
+		return orderedStage(in, workers, fn)
+	}
+	p := in.p
+	out := make(chan R)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		p.spawn(func(ctx context.Context) error {
+			defer wg.Done()
+			for {
+				value, ok := receive(ctx, in.ch)
+				if !ok {
+					return nil
+				}
+				result, err := fn(ctx, value)
+				if err != nil {
+					return err
+				}
+				if !send(ctx, out, result) {
+					return nil
+				}
+			}
+		})
+	}
+	p.spawn(func(ctx context.Context) error {
+		wg.Wait()
+		close(out)
+		return nil
+	})
+	return &Stream[R]{p: p, ch: out}
+}
+
+// orderedJob is a value of an ordered stage along with where its result goes.
+type orderedJob[T, R any] struct {
+	value  T
+	result chan R
+}
+
+// orderedStage is Stage in Ordered mode. Every value gets a result channel, queued in
+// input order; results are emitted by walking that queue. The queue is bounded, so at
+// most 2*workers values are in flight.
+func orderedStage[T, R any](in *Stream[T], workers int, fn func(context.Context, T) (R, error)) *Stream[R] {
+	p := in.p
+	jobs := make(chan orderedJob[T, R], workers)
+	queue := make(chan chan R, workers)
+	out := make(chan R)
+
+	p.spawn(func(ctx context.Context) error {
+		defer close(jobs)
+		defer close(queue)
+		for {
+			value, ok := receive(ctx, in.ch)
+			if !ok {
+				return nil
+			}
+			job := orderedJob[T, R]{value: value, result: make(chan R, 1)}
+			if !send(ctx, queue, job.result) || !send(ctx, jobs, job) {
+				return nil
+			}
+		}
+	})
+	for i := 0; i < workers; i++ {
+		p.spawn(func(ctx context.Context) error {
+			for {
+				job, ok := receive(ctx, jobs)
+				if !ok {
+					return nil
+				}
+				result, err := fn(ctx, job.value)
+				if err != nil {
+					return err
+				}
+				job.result <- result
+			}
+		})
+	}
+	p.spawn(func(ctx context.Context) error {
+		defer close(out)
+		for {
+			next, ok := receive(ctx, queue)
+			if !ok {
+				return nil
+			}
+			result, ok := receive(ctx, next)
+			if !ok || !send(ctx, out, result) {
+				return nil
+			}
+		}
+	})
+	return &Stream[R]{p: p, ch: out}
+}
+
+// Batch groups the values of in into slices of up to size values. A batch is emitted
+// once full, or timeout after its first value arrived, whichever comes first. A timeout
+// of 0 or less only emits full batches, and the last one.
+func Batch[T any](in *Stream[T], size int, timeout time.Duration) *Stream[[]T] {
+	if size < 1 {
+		size = 1
+	}
+	p := in.p
+	out := make(chan []T)
+	p.spawn(func(ctx context.Context) error {
+		defer close(out)
+		var batch []T
+		var timer *time.Timer
+		var expired <-chan time.Time
+		flush := func() bool {
+			if timer != nil {
+				timer.Stop()
+				timer, expired = nil, nil
+			}
+			if len(batch) == 0 {
+				return true
+			}
+			full := batch
+			batch = nil
+			return send(ctx, out, full)
+		}
+		for {
+			select {
+			case value, ok := <-in.ch:
+				if !ok {
+					flush()
+					return nil
+				}
+				batch = append(batch, value)
+				if len(batch) >= size {
+					if !flush() {
+						return nil
+					}
+				} else if len(batch) == 1 && timeout > 0 {
+					timer = time.NewTimer(timeout)
+					expired = timer.C
+				}
+			case <-expired:
+				if !flush() {
+					return nil
+				}
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	})
+	return &Stream[[]T]{p: p, ch: out}
+}
+
+// Sink consumes in with fn and waits for every stage of the pipeline to exit. It is the
+// last step of a pipeline and must be called once. It returns the first error of the
+// pipeline, or the error of the pipeline's context if it was canceled from outside.
+func Sink[T any](in *Stream[T], fn func(context.Context, T) error) error {
+	p := in.p
+	p.spawn(func(ctx context.Context) error {
+		for {
+			value, ok := receive(ctx, in.ch)
+			if !ok {
+				return nil
+			}
+			if err := fn(ctx, value); err != nil {
+				return err
+			}
+		}
+	})
+	p.wg.Wait()
+	defer p.cancel()
+	if p.err != nil {
+		return p.err
+	}
+	return p.ctx.Err()
+}
@@ -0,0 +1,196 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPipelineStage(t *testing.T) {
+	items := make([]int, 100)
+	for k := range items {
+		items[k] = k
+	}
+	square := func(ctx context.Context, n int) (int, error) {
+		time.Sleep(time.Duration(rand.IntN(500)) * time.Microsecond)
+		return n * n, nil
+	}
+
+	tests := []struct {
+		name string
+		mode StageMode
+	}{
+		{name: "Ordered", mode: Ordered},
+		{name: "Unordered", mode: Unordered},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPipeline(context.Background())
+			var got []int
+			err := Sink(Stage(FromSlice(p, items), 8, square, tt.mode), func(ctx context.Context, n int) error {
+				got = append(got, n)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Sink() unexpected error = %v", err)
+			}
+			if len(got) != len(items) {
+				t.Fatalf("Sink() got %d values, want %d", len(got), len(items))
+			}
+			if tt.mode == Unordered {
+				sort.Ints(got)
+			}
+			for k, n := range items {
+				if got[k] != n*n {
+					t.Errorf("Expected %v, Got %v at Index: %d", n*n, got[k], k)
+				}
+			}
+		})
+	}
+}
+
+func TestPipelineBatch(t *testing.T) {
+	p := NewPipeline(context.Background())
+	source := Source(p, func(ctx context.Context, emit func(int) error) error {
+		for i := 1; i <= 7; i++ {
+			if err := emit(i); err != nil {
+				return err
+			}
+		}
+		time.Sleep(50 * time.Millisecond) // the pending batch times out meanwhile
+		return emit(8)
+	})
+
+	var sizes []int
+	err := Sink(Batch(source, 3, 10*time.Millisecond), func(ctx context.Context, batch []int) error {
+		sizes = append(sizes, len(batch))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Sink() unexpected error = %v", err)
+	}
+	want := []int{3, 3, 1, 1}
+	if len(sizes) != len(want) {
+		t.Fatalf("Batch() sizes = %v, want %v", sizes, want)
+	}
+	for k := range want {
+		if sizes[k] != want[k] {
+			t.Errorf("Batch() sizes = %v, want %v", sizes, want)
+		}
+	}
+}
+
+func TestPipelineErrors(t *testing.T) {
+	errBoom := errors.New("boom")
+	endless := func(p *Pipeline) *Stream[int] {
+		return Source(p, func(ctx context.Context, emit func(int) error) error {
+			for i := 0; ; i++ {
+				if err := emit(i); err != nil {
+					return err
+				}
+			}
+		})
+	}
+
+	tests := []struct {
+		name    string
+		ctx     func() (context.Context, context.CancelFunc)
+		stage   func(context.Context, int) (int, error)
+		sink    func(context.Context, int) error
+		wantErr error
+	}{
+		{
+			name: "Stage error",
+			stage: func(ctx context.Context, n int) (int, error) {
+				if n == 10 {
+					return 0, errBoom
+				}
+				return n, nil
+			},
+			wantErr: errBoom,
+		},
+		{
+			name: "Sink error",
+			sink: func(ctx context.Context, n int) error {
+				if n == 10 {
+					return errBoom
+				}
+				return nil
+			},
+			wantErr: errBoom,
+		},
+		{
+			name:    "Stage panic",
+			stage:   func(ctx context.Context, n int) (int, error) { panic(errBoomPanic) },
+			wantErr: errBoomPanic,
+		},
+		{
+			name: "Context deadline",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 20*time.Millisecond)
+			},
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.ctx != nil {
+				ctx, cancel = tt.ctx()
+			}
+			defer cancel()
+			stage, sink := tt.stage, tt.sink
+			if stage == nil {
+				stage = func(ctx context.Context, n int) (int, error) { return n, nil }
+			}
+			if sink == nil {
+				sink = func(ctx context.Context, n int) error { return nil }
+			}
+
+			p := NewPipeline(ctx)
+			done := make(chan error)
+			go func() { done <- Sink(Stage(endless(p), 4, stage, Ordered), sink) }()
+			select {
+			case err := <-done:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Sink() error = %v, want %v", err, tt.wantErr)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("Sink() did not return, pipeline not stopped")
+			}
+		})
+	}
+}
+
+func TestPipelineBackpressure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var produced int32
+	p := NewPipeline(ctx)
+	source := Source(p, func(ctx context.Context, emit func(int) error) error {
+		for i := 0; ; i++ {
+			if err := emit(i); err != nil {
+				return err
+			}
+			atomic.AddInt32(&produced, 1)
+		}
+	})
+	stage := Stage(source, 2, func(ctx context.Context, n int) (int, error) { return n, nil }, Ordered)
+
+	var once sync.Once
+	go Sink(stage, func(ctx context.Context, n int) error {
+		once.Do(func() { <-ctx.Done() }) // stuck on the first value
+		return nil
+	})
+	time.Sleep(50 * time.Millisecond)
+	if got := atomic.LoadInt32(&produced); got > 10 {
+		t.Errorf("Source produced %d values ahead of a blocked sink, want it bounded", got)
+	}
+	cancel()
+}
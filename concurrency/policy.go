@@ -0,0 +1,108 @@
+package concurrency
+
+import (
+	"errors"
+	"fmt"
+)
+
+// policy decides how a parallel run reacts to failing tasks. The zero value runs
+// every task to completion.
+type policy int
+
+const (
+	policyCollectAll policy = iota
+	policyFailFast
+	policyBestEffort
+)
+
+// TaskError is the error of a single task, along with its index in the input.
+// Errors returned by Map are made of TaskErrors, use errors.As to find which task
+// failed and errors.Is to match the underlying error.
+type TaskError struct {
+	Index int
+	Err   error
+}
+
+// Error implements the error interface.
+func (e *TaskError) Error() string {
+	return fmt.Sprintf("task %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the error of the task.
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+// WithFailFast cancels the remaining tasks as soon as one fails and returns the
+// *TaskError of that task. It is the default policy of Map and Run.
+func WithFailFast() Option {
+	return func(c *config) {
+		c.policy = policyFailFast
+	}
+}
+
+// WithCollectAll runs every task to completion whatever the failures, and returns all
+// the errors joined with errors.Join, one *TaskError per failed task. It is the default
+// policy of ParallelizeCtx.
+func WithCollectAll() Option {
+	return func(c *config) {
+		c.policy = policyCollectAll
+	}
+}
+
+// WithBestEffort tolerates up to maxFailures failed tasks: their results are left as
+// zero values and no error is returned. One more failure cancels the remaining tasks
+// and returns all the errors joined, like WithCollectAll.
+func WithBestEffort(maxFailures int) Option {
+	return func(c *config) {
+		c.policy = policyBestEffort
+		c.maxFailures = maxFailures
+	}
+}
+
+// cancels reports whether the run must be canceled after the given number of failures.
+func (c *config) cancels(failures int) bool {
+	switch c.policy {
+	case policyFailFast:
+		return true
+	case policyBestEffort:
+		return failures > c.maxFailures
+	}
+	return false
+}
+
+// err returns the error of a run from the errors of its tasks, according to the policy.
+func (c *config) err(errorSet []error, firstErr *TaskError) error {
+	var failed []error
+	for k, err := range errorSet {
+		if err != nil {
+			failed = append(failed, &TaskError{Index: k, Err: err})
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	switch c.policy {
+	case policyFailFast:
+		if firstErr != nil {
+			return firstErr
+		}
+		// No task failed, the run was canceled from outside.
+		return failed[0].(*TaskError).Err
+	case policyBestEffort:
+		var canceledErr error
+		failures := 0
+		for _, err := range failed {
+			if err := err.(*TaskError).Err; errors.Is(err, ErrCanceled) {
+				canceledErr = err
+			} else {
+				failures++
+			}
+		}
+		if failures <= c.maxFailures {
+			// Tasks are only canceled from outside while failures are tolerated.
+			return canceledErr
+		}
+	}
+	return errors.Join(failed...)
+}
@@ -0,0 +1,124 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// taskIndexes returns the indexes of the TaskErrors joined in err.
+func taskIndexes(err error) []int {
+	var indexes []int
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return indexes
+	}
+	for _, e := range joined.Unwrap() {
+		var terr *TaskError
+		if errors.As(e, &terr) {
+			indexes = append(indexes, terr.Index)
+		}
+	}
+	return indexes
+}
+
+func TestMapPolicies(t *testing.T) {
+	errBoom := errors.New("boom")
+	// Tasks 1 and 3 fail, the others succeed after a short delay, or stop on cancellation.
+	fn := func(ctx context.Context, n int) (int, error) {
+		if n == 1 || n == 3 {
+			return 0, errBoom
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(20 * time.Millisecond):
+			return n * 10, nil
+		}
+	}
+
+	tests := []struct {
+		name        string
+		opts        []Option
+		wantErr     bool
+		wantIndexes []int
+		wantResults []int
+	}{
+		{name: "Collect all", opts: []Option{WithCollectAll()}, wantErr: true, wantIndexes: []int{1, 3}, wantResults: []int{0, 0, 20, 0, 40}},
+		{name: "Best effort within threshold", opts: []Option{WithBestEffort(2)}, wantErr: false, wantResults: []int{0, 0, 20, 0, 40}},
+		{name: "Best effort over threshold", opts: []Option{WithBestEffort(1)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Map(context.Background(), []int{0, 1, 2, 3, 4}, fn, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Map() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, errBoom) {
+				t.Errorf("Map() error = %v, want it to wrap %v", err, errBoom)
+			}
+			if tt.wantIndexes != nil {
+				got := taskIndexes(err)
+				if len(got) != len(tt.wantIndexes) {
+					t.Fatalf("Map() failed tasks = %v, want %v", got, tt.wantIndexes)
+				}
+				for k := range got {
+					if got[k] != tt.wantIndexes[k] {
+						t.Errorf("Map() failed tasks = %v, want %v", got, tt.wantIndexes)
+					}
+				}
+			}
+			for k, want := range tt.wantResults {
+				if result[k] != want {
+					t.Errorf("Expected %v, Got %v at Index: %d", want, result[k], k)
+				}
+			}
+		})
+	}
+}
+
+func TestMapFailFastTaskError(t *testing.T) {
+	errBoom := errors.New("boom")
+	_, err := Map(context.Background(), []int{0, 1, 2}, func(ctx context.Context, n int) (int, error) {
+		if n == 1 {
+			return 0, errBoom
+		}
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}, WithFailFast())
+
+	var terr *TaskError
+	if !errors.As(err, &terr) || terr.Index != 1 || terr.Err != errBoom {
+		t.Errorf("Map() error = %v, want task 1 failing with %v", err, errBoom)
+	}
+}
+
+func TestMapBestEffortCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := Map(ctx, []int{0, 1}, func(ctx context.Context, n int) (int, error) {
+		return n, nil
+	}, WithBestEffort(5))
+	if !errors.Is(err, ErrCanceled) {
+		t.Errorf("Map() error = %v, want %v", err, ErrCanceled)
+	}
+}
+
+func TestParallelizeCtxFailFast(t *testing.T) {
+	errBoom := errors.New("boom")
+	_, errs := ParallelizeCtx(context.Background(), []func(context.Context) (interface{}, error){
+		func(ctx context.Context) (interface{}, error) { return nil, errBoom },
+		func(ctx context.Context) (interface{}, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}, WithFailFast())
+	if errs[0] != errBoom {
+		t.Errorf("Expected %v, Got %v at Index: 0", errBoom, errs[0])
+	}
+	if !errors.Is(errs[1], context.Canceled) {
+		t.Errorf("Expected sibling to be canceled, Got %v at Index: 1", errs[1])
+	}
+}
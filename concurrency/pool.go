@@ -0,0 +1,200 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrPoolFull is returned by Submit when the queue of a pool using Reject is full.
+var ErrPoolFull = errors.New("pool queue is full")
+
+// ErrPoolClosed is returned by Submit once Shutdown has been called.
+var ErrPoolClosed = errors.New("pool is shut down")
+
+// Backpressure decides what Submit does when the queue of a pool is full.
+type Backpressure int
+
+const (
+	// Block waits until there is room in the queue.
+	Block Backpressure = iota
+	// Reject fails with ErrPoolFull.
+	Reject
+	// CallerRuns runs the task right away in the goroutine calling Submit, which slows
+	// down the caller as much as the pool is behind.
+	CallerRuns
+)
+
+// PoolConfig takes up the configuration of a Pool.
+type PoolConfig struct {
+	// Workers is the number of goroutines running tasks, runtime.NumCPU() if not set.
+	Workers int
+	// QueueSize is the number of tasks waiting for a worker. With 0, a task is only
+	// accepted when a worker is free to take it.
+	QueueSize int
+	// Backpressure applies when the queue is full, Block by default.
+	Backpressure Backpressure
+}
+
+// PoolStats is a snapshot of the state of a Pool.
+type PoolStats struct {
+	Workers   int    // number of workers
+	Active    int    // tasks being run by workers
+	Queued    int    // tasks waiting for a worker
+	Submitted uint64 // tasks accepted since the pool was created
+	Completed uint64 // tasks finished since the pool was created
+	Rejected  uint64 // tasks refused with ErrPoolFull
+}
+
+// Pool is a long lived set of workers running submitted tasks, so that busy services
+// don't pay for new goroutines on every call and keep the number of concurrent tasks
+// bounded. Use Submit to run a task and Shutdown to stop the pool.
+type Pool struct {
+	config  PoolConfig
+	queue   chan func()
+	workers sync.WaitGroup
+	// guards closed, so that queue is never written to once closed.
+	mutex  sync.RWMutex
+	closed bool
+	// closing is closed when Shutdown starts, to release the submitters blocked on a full
+	// queue, which hold mutex.
+	closing   chan struct{}
+	closeOnce sync.Once
+
+	active    int32
+	submitted uint64
+	completed uint64
+	rejected  uint64
+}
+
+// NewPool starts the workers of a new pool.
+//
+// Usage:
+//
+//	pool := NewPool(PoolConfig{Workers: 8, QueueSize: 100, Backpressure: Reject})
+//	defer pool.Shutdown(context.Background())
+//	future, err := Submit(pool, func() (*User, error) { return fetchUser(1) })
+//	user, err := future.Await(ctx)
+func NewPool(config PoolConfig) *Pool {
+	if config.Workers <= 0 {
+		config.Workers = runtime.NumCPU()
+	}
+	if config.QueueSize < 0 {
+		config.QueueSize = 0
+	}
+	p := &Pool{
+		config:  config,
+		queue:   make(chan func(), config.QueueSize),
+		closing: make(chan struct{}),
+	}
+	p.workers.Add(config.Workers)
+	for i := 0; i < config.Workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// work runs queued tasks until the queue is closed and drained.
+func (p *Pool) work() {
+	defer p.workers.Done()
+	for task := range p.queue {
+		atomic.AddInt32(&p.active, 1)
+		task()
+		atomic.AddInt32(&p.active, -1)
+		atomic.AddUint64(&p.completed, 1)
+	}
+}
+
+// Submit queues fn to be run by a worker of p and returns a future of its result.
+// When the queue is full, Submit blocks, fails with ErrPoolFull or runs fn itself,
+// according to the pool's Backpressure. A task that panics fails with a *PanicError.
+func Submit[R any](p *Pool, fn func() (R, error)) (*Future[R], error) {
+	future := newFuture[R]()
+	task := func() {
+		future.complete(safeCall(context.Background(), 0, func(context.Context, int) (R, error) {
+			return fn()
+		}))
+	}
+
+	runInCaller, err := p.enqueue(task)
+	if err != nil {
+		return nil, err
+	}
+	if runInCaller {
+		task()
+		atomic.AddUint64(&p.completed, 1)
+	}
+	return future, nil
+}
+
+// enqueue hands task to the workers. It returns true if the queue is full and the
+// caller must run task itself.
+func (p *Pool) enqueue(task func()) (bool, error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if p.closed {
+		return false, ErrPoolClosed
+	}
+	switch p.config.Backpressure {
+	case Reject, CallerRuns:
+		select {
+		case p.queue <- task:
+		default:
+			if p.config.Backpressure == CallerRuns {
+				atomic.AddUint64(&p.submitted, 1)
+				return true, nil
+			}
+			atomic.AddUint64(&p.rejected, 1)
+			return false, ErrPoolFull
+		}
+	default:
+		// Shutdown waits for mutex to close the queue, so give up once it started.
+		select {
+		case p.queue <- task:
+		case <-p.closing:
+			return false, ErrPoolClosed
+		}
+	}
+	atomic.AddUint64(&p.submitted, 1)
+	return false, nil
+}
+
+// Shutdown stops accepting tasks and waits for the queued and running ones to finish.
+// Submit calls blocked on a full queue fail with ErrPoolClosed.
+// If ctx is done first, Shutdown returns ctx's error and the remaining tasks still
+// finish in the background. It is safe to call Shutdown more than once.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.closeOnce.Do(func() { close(p.closing) })
+	p.mutex.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mutex.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		p.workers.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats returns the current metrics of the pool.
+func (p *Pool) Stats() PoolStats {
+	return PoolStats{
+		Workers:   p.config.Workers,
+		Active:    int(atomic.LoadInt32(&p.active)),
+		Queued:    len(p.queue),
+		Submitted: atomic.LoadUint64(&p.submitted),
+		Completed: atomic.LoadUint64(&p.completed),
+		Rejected:  atomic.LoadUint64(&p.rejected),
+	}
+}
@@ -0,0 +1,185 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPoolSubmit(t *testing.T) {
+	pool := NewPool(PoolConfig{Workers: 3, QueueSize: 10})
+	defer pool.Shutdown(context.Background())
+
+	futures := make([]*Future[int], 10)
+	for i := range futures {
+		i := i
+		future, err := Submit(pool, func() (int, error) { return i * i, nil })
+		if err != nil {
+			t.Fatalf("Submit() unexpected error = %v", err)
+		}
+		futures[i] = future
+	}
+	for i, future := range futures {
+		got, err := future.Await(context.Background())
+		if err != nil || got != i*i {
+			t.Errorf("Await() = %v, %v, want %v", got, err, i*i)
+		}
+	}
+
+	future, _ := Submit(pool, func() (int, error) { panic(errBoomPanic) })
+	if _, err := future.Await(context.Background()); !errors.Is(err, errBoomPanic) {
+		t.Errorf("Await() error = %v, want panic of %v", err, errBoomPanic)
+	}
+}
+
+func TestPoolBackpressure(t *testing.T) {
+	tests := []struct {
+		name         string
+		backpressure Backpressure
+		wantErr      error
+		wantRejected uint64
+	}{
+		{name: "Reject", backpressure: Reject, wantErr: ErrPoolFull, wantRejected: 1},
+		{name: "CallerRuns", backpressure: CallerRuns},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := NewPool(PoolConfig{Workers: 1, QueueSize: 1, Backpressure: tt.backpressure})
+			release := make(chan struct{})
+			started := make(chan struct{})
+			Submit(pool, func() (int, error) {
+				close(started)
+				<-release
+				return 1, nil
+			})
+			<-started
+			Submit(pool, func() (int, error) { return 2, nil }) // fills the queue
+
+			future, err := Submit(pool, func() (int, error) { return 3, nil })
+			if err != tt.wantErr {
+				t.Fatalf("Submit() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil {
+				// Ran by the caller, so done before Submit returned.
+				select {
+				case <-future.Done():
+				default:
+					t.Errorf("Expected the task to run in the caller")
+				}
+			}
+
+			stats := pool.Stats()
+			if stats.Active != 1 || stats.Queued != 1 || stats.Rejected != tt.wantRejected {
+				t.Errorf("Stats() = %+v", stats)
+			}
+			close(release)
+			pool.Shutdown(context.Background())
+		})
+	}
+}
+
+func TestPoolBlock(t *testing.T) {
+	pool := NewPool(PoolConfig{Workers: 1, Backpressure: Block})
+	release := make(chan struct{})
+	Submit(pool, func() (int, error) {
+		<-release
+		return 1, nil
+	})
+
+	submitted := make(chan struct{})
+	go func() {
+		Submit(pool, func() (int, error) { return 2, nil })
+		close(submitted)
+	}()
+	select {
+	case <-submitted:
+		t.Fatalf("Expected Submit to block while the only worker is busy")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	<-submitted
+	pool.Shutdown(context.Background())
+}
+
+func TestPoolShutdown(t *testing.T) {
+	pool := NewPool(PoolConfig{Workers: 1, QueueSize: 5})
+	var futures []*Future[int]
+	for i := 0; i < 5; i++ {
+		i := i
+		future, _ := Submit(pool, func() (int, error) {
+			time.Sleep(time.Millisecond)
+			return i, nil
+		})
+		futures = append(futures, future)
+	}
+
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() unexpected error = %v", err)
+	}
+	for i, future := range futures {
+		select {
+		case <-future.Done():
+		default:
+			t.Errorf("Expected queued task %d to be drained by Shutdown", i)
+		}
+	}
+	if _, err := Submit(pool, func() (int, error) { return 0, nil }); err != ErrPoolClosed {
+		t.Errorf("Submit() after Shutdown error = %v, want %v", err, ErrPoolClosed)
+	}
+	if stats := pool.Stats(); stats.Submitted != 5 || stats.Completed != 5 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
+
+func TestPoolShutdownTimeout(t *testing.T) {
+	pool := NewPool(PoolConfig{Workers: 1})
+	release := make(chan struct{})
+	defer close(release)
+	Submit(pool, func() (int, error) {
+		<-release
+		return 0, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := pool.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestPoolShutdownBlockedSubmitter(t *testing.T) {
+	pool := NewPool(PoolConfig{Workers: 1, Backpressure: Block})
+	release := make(chan struct{})
+	defer close(release)
+	Submit(pool, func() (int, error) {
+		<-release
+		return 0, nil
+	})
+
+	submitErr := make(chan error, 1)
+	go func() {
+		_, err := Submit(pool, func() (int, error) { return 0, nil })
+		submitErr <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := pool.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Shutdown() took %v with a blocked submitter, want about the ctx timeout", elapsed)
+	}
+	select {
+	case err := <-submitErr:
+		if err != ErrPoolClosed {
+			t.Errorf("Submit() blocked during Shutdown error = %v, want %v", err, ErrPoolClosed)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected the blocked Submit to return once Shutdown started")
+	}
+}
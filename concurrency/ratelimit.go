@@ -0,0 +1,138 @@
+package concurrency
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sanksons/gowraps/hmap"
+)
+
+// RateLimiter is a token bucket: it holds up to burst tokens, refilled at rate tokens
+// per second, and every call takes one. It is safe for concurrent use.
+//
+// Usage:
+//
+//	limiter := NewRateLimiter(10, 5) // 10 calls per second, bursts of 5
+//	if err := limiter.Wait(ctx); err != nil {
+//		return err
+//	}
+//	callPartnerAPI()
+type RateLimiter struct {
+	mutex  sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	// now returns the current time, replaced in tests.
+	now func() time.Time
+}
+
+// NewRateLimiter returns a limiter allowing rate calls per second on average and up to
+// burst calls at once. The bucket starts full. A rate of 0 or less means no limit.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now(), now: time.Now}
+}
+
+// refill adds the tokens earned since the last call. Must be called with mutex held.
+func (l *RateLimiter) refill(now time.Time) {
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens = min(l.burst, l.tokens+elapsed.Seconds()*l.rate)
+		l.last = now
+	}
+}
+
+// Allow takes a token if one is available right away and reports whether it did.
+func (l *RateLimiter) Allow() bool {
+	if l.rate <= 0 {
+		return true
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.refill(l.now())
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// Wait blocks until a token is available and takes it. If ctx is done first, Wait
+// returns ctx's error and no token is taken, even if one was available.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil || l.rate <= 0 {
+		return err
+	}
+	l.mutex.Lock()
+	l.refill(l.now())
+	// Reserve the token now, the bucket may go negative: later callers then wait for
+	// this reservation to be paid back first, which keeps the order fair.
+	l.tokens--
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mutex.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mutex.Lock()
+		l.tokens = min(l.burst, l.tokens+1)
+		l.mutex.Unlock()
+		return ctx.Err()
+	}
+}
+
+// WithRateLimit paces the start of the tasks of a parallel run with limiter. A limiter
+// can be shared between runs to enforce a global rate.
+func WithRateLimit(limiter *RateLimiter) Option {
+	return func(c *config) {
+		c.rateLimiter = limiter
+	}
+}
+
+// KeyedRateLimiter holds a separate RateLimiter per key, e.g. per partner or per user,
+// all with the same rate and burst. Limiters are created on first use and kept until
+// removed with Remove.
+type KeyedRateLimiter struct {
+	rate     float64
+	burst    int
+	limiters *hmap.Map
+}
+
+// NewKeyedRateLimiter returns a KeyedRateLimiter whose limiters allow rate calls per
+// second and bursts of burst calls.
+func NewKeyedRateLimiter(rate float64, burst int) *KeyedRateLimiter {
+	return &KeyedRateLimiter{rate: rate, burst: burst, limiters: hmap.New()}
+}
+
+// Get returns the limiter of key, creating it if needed.
+func (k *KeyedRateLimiter) Get(key interface{}) *RateLimiter {
+	if limiter, found := k.limiters.Get(key); found {
+		return limiter.(*RateLimiter)
+	}
+	limiter, _ := k.limiters.GetOrPut(key, NewRateLimiter(k.rate, k.burst))
+	return limiter.(*RateLimiter)
+}
+
+// Allow takes a token from the limiter of key if one is available right away.
+func (k *KeyedRateLimiter) Allow(key interface{}) bool {
+	return k.Get(key).Allow()
+}
+
+// Wait blocks until a token of the limiter of key is available and takes it.
+func (k *KeyedRateLimiter) Wait(ctx context.Context, key interface{}) error {
+	return k.Get(key).Wait(ctx)
+}
+
+// Remove drops the limiter of key, the next call for key starts with a full bucket.
+func (k *KeyedRateLimiter) Remove(key interface{}) {
+	k.limiters.Remove(key)
+}
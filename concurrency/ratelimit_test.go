@@ -0,0 +1,115 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// newTestLimiter returns a limiter whose clock only moves when the returned func is called.
+func newTestLimiter(rate float64, burst int) (*RateLimiter, func(time.Duration)) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := NewRateLimiter(rate, burst)
+	l.now = func() time.Time { return now }
+	l.last = now
+	return l, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	l, advance := newTestLimiter(10, 3)
+
+	tests := []struct {
+		name    string
+		advance time.Duration
+		calls   int
+		want    int // number of allowed calls
+	}{
+		{name: "Burst", calls: 5, want: 3},
+		{name: "Empty bucket", calls: 1, want: 0},
+		{name: "Refill one token", advance: 100 * time.Millisecond, calls: 2, want: 1},
+		{name: "Refill caps at burst", advance: time.Hour, calls: 5, want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			advance(tt.advance)
+			allowed := 0
+			for i := 0; i < tt.calls; i++ {
+				if l.Allow() {
+					allowed++
+				}
+			}
+			if allowed != tt.want {
+				t.Errorf("Allow() allowed %d calls, want %d", allowed, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	l := NewRateLimiter(100, 1)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait() unexpected error = %v", err)
+		}
+	}
+	// First call is free, the 4 others wait 10ms each.
+	if elapsed := time.Since(start); elapsed < 35*time.Millisecond {
+		t.Errorf("Wait() let 5 calls through in %v, want about 40ms", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	slow := NewRateLimiter(0.1, 1)
+	slow.Allow()
+	if err := slow.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if slow.tokens < -0.01 {
+		t.Errorf("Wait() kept the token of a canceled call, tokens = %v", slow.tokens)
+	}
+
+	// A done ctx fails even when a token is available, or there is no limit.
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, limiter := range []*RateLimiter{NewRateLimiter(100, 1), NewRateLimiter(0, 1)} {
+		if err := limiter.Wait(canceled); !errors.Is(err, context.Canceled) {
+			t.Errorf("Wait() of rate %v error = %v, want %v", limiter.rate, err, context.Canceled)
+		}
+	}
+	if l := NewRateLimiter(100, 1); l.Wait(canceled) == nil || !l.Allow() {
+		t.Errorf("Wait() with a done ctx took the available token")
+	}
+}
+
+func TestKeyedRateLimiter(t *testing.T) {
+	k := NewKeyedRateLimiter(1, 1)
+	if !k.Allow("partner-a") || !k.Allow("partner-b") {
+		t.Errorf("Allow() expected a token per key")
+	}
+	if k.Allow("partner-a") {
+		t.Errorf("Allow() expected partner-a to be limited")
+	}
+	if k.Get("partner-a") != k.Get("partner-a") {
+		t.Errorf("Get() expected the same limiter for the same key")
+	}
+	k.Remove("partner-a")
+	if !k.Allow("partner-a") {
+		t.Errorf("Allow() expected a full bucket after Remove")
+	}
+}
+
+func TestMapWithRateLimit(t *testing.T) {
+	start := time.Now()
+	_, err := Map(context.Background(), []int{1, 2, 3, 4, 5}, func(ctx context.Context, n int) (int, error) {
+		return n, nil
+	}, WithRateLimit(NewRateLimiter(100, 1)))
+	if err != nil {
+		t.Fatalf("Map() unexpected error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 35*time.Millisecond {
+		t.Errorf("Map() started 5 tasks in %v, want about 40ms", elapsed)
+	}
+}
@@ -0,0 +1,180 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Jitter randomizes the delays between retries, so that clients failing together don't
+// retry together.
+type Jitter int
+
+const (
+	// NoJitter waits exactly the exponential backoff delay.
+	NoJitter Jitter = iota
+	// FullJitter waits a random delay between 0 and the exponential backoff delay.
+	FullJitter
+	// DecorrelatedJitter waits a random delay between InitialDelay and three times the
+	// previous delay.
+	DecorrelatedJitter
+)
+
+// RetryPolicy configures Retry. Fields left to their zero value take the value of
+// DefaultRetryPolicy.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of calls, including the first one. A negative
+	// value means no limit, retries then stop with MaxElapsedTime or the context.
+	MaxAttempts int
+	// InitialDelay is the delay before the first retry.
+	InitialDelay time.Duration
+	// MaxDelay caps the delay between two attempts.
+	MaxDelay time.Duration
+	// Multiplier grows the delay after every attempt.
+	Multiplier float64
+	// Jitter randomizes the delays.
+	Jitter Jitter
+	// MaxElapsedTime stops retrying once the next attempt would start this long after
+	// the first one. No limit if not set.
+	MaxElapsedTime time.Duration
+	// Retryable tells whether a failed attempt is worth retrying. All errors are
+	// retried if not set, except the ones wrapped with Permanent.
+	Retryable func(error) bool
+}
+
+// DefaultRetryPolicy makes 3 attempts with exponential backoff from 100ms and full jitter.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:  3,
+	InitialDelay: 100 * time.Millisecond,
+	MaxDelay:     10 * time.Second,
+	Multiplier:   2,
+	Jitter:       FullJitter,
+}
+
+// permanentError marks an error that must not be retried.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so that Retry returns it right away instead of retrying. Retry
+// returns err itself, not the wrapper.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Retry calls fn until it succeeds, waiting with exponential backoff between attempts
+// as configured by policy. It returns the result of the last attempt.
+//
+// Retrying stops when the error is not retryable, on MaxAttempts or MaxElapsedTime, or
+// when ctx is done while waiting; the error then wraps both ctx's error and the error of
+// the last attempt.
+//
+// Usage:
+//
+//	user, err := Retry(ctx, func(ctx context.Context) (*User, error) {
+//		return fetchUser(ctx, id)
+//	}, RetryPolicy{MaxAttempts: 5, Retryable: mysqldb.IsRetryable})
+func Retry[R any](ctx context.Context, fn func(context.Context) (R, error), policy RetryPolicy) (R, error) {
+	policy = policy.withDefaults()
+	start := time.Now()
+	var delay time.Duration
+	for attempt := 1; ; attempt++ {
+		value, err := fn(ctx)
+		if err == nil {
+			return value, nil
+		}
+		var perm *permanentError
+		if errors.As(err, &perm) {
+			return value, perm.err
+		}
+		if !policy.Retryable(err) || (policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts) {
+			return value, err
+		}
+		delay = policy.backoff(attempt, delay)
+		if policy.MaxElapsedTime > 0 && time.Since(start)+delay > policy.MaxElapsedTime {
+			return value, err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return value, fmt.Errorf("%w: %w", ctx.Err(), err)
+		}
+	}
+}
+
+// WithRetry retries every task of a parallel run according to policy. Tasks that
+// panic are not retried.
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *config) {
+		c.retry = &policy
+	}
+}
+
+// retryTask wraps task so that every call is retried according to policy.
+func retryTask[R any](task func(context.Context, int) (R, error), policy RetryPolicy) func(context.Context, int) (R, error) {
+	return func(ctx context.Context, offset int) (R, error) {
+		return Retry(ctx, func(ctx context.Context) (R, error) {
+			return task(ctx, offset)
+		}, policy)
+	}
+}
+
+// withDefaults fills the unset fields of p from DefaultRetryPolicy.
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if p.InitialDelay <= 0 {
+		p.InitialDelay = DefaultRetryPolicy.InitialDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = DefaultRetryPolicy.MaxDelay
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = DefaultRetryPolicy.Multiplier
+	}
+	if p.Retryable == nil {
+		p.Retryable = func(error) bool { return true }
+	}
+	return p
+}
+
+// backoff returns the delay to wait after the given attempt, prev being the delay
+// waited after the previous one.
+func (p RetryPolicy) backoff(attempt int, prev time.Duration) time.Duration {
+	if p.Jitter == DecorrelatedJitter {
+		if prev < p.InitialDelay {
+			prev = p.InitialDelay
+		}
+		upper := min(3*prev, p.MaxDelay)
+		return p.InitialDelay + randomDuration(upper-p.InitialDelay)
+	}
+	delay := p.MaxDelay
+	if d := float64(p.InitialDelay) * math.Pow(p.Multiplier, float64(attempt-1)); d < float64(p.MaxDelay) {
+		delay = time.Duration(d)
+	}
+	if p.Jitter == FullJitter {
+		return randomDuration(delay)
+	}
+	return delay
+}
+
+// randomDuration returns a random duration in [0, n).
+func randomDuration(n time.Duration) time.Duration {
+	if n <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(n)))
+}
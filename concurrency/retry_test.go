@@ -0,0 +1,130 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	errTransient := errors.New("transient")
+	errFatal := errors.New("fatal")
+	fast := RetryPolicy{MaxAttempts: 4, InitialDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+
+	tests := []struct {
+		name         string
+		policy       RetryPolicy
+		failures     []error // errors returned by the attempts, then success
+		wantErr      error
+		wantAttempts int
+	}{
+		{name: "Succeeds after retries", policy: fast, failures: []error{errTransient, errTransient}, wantAttempts: 3},
+		{name: "Max attempts", policy: fast, failures: []error{errTransient, errTransient, errTransient, errTransient, errTransient}, wantErr: errTransient, wantAttempts: 4},
+		{name: "Permanent", policy: fast, failures: []error{Permanent(errFatal)}, wantErr: errFatal, wantAttempts: 1},
+		{
+			name: "Not retryable",
+			policy: RetryPolicy{MaxAttempts: 4, InitialDelay: time.Millisecond, Retryable: func(err error) bool {
+				return err == errTransient
+			}},
+			failures:     []error{errTransient, errFatal},
+			wantErr:      errFatal,
+			wantAttempts: 2,
+		},
+		{
+			name:         "Max elapsed time",
+			policy:       RetryPolicy{MaxAttempts: -1, InitialDelay: 20 * time.Millisecond, Jitter: NoJitter, MaxElapsedTime: 50 * time.Millisecond},
+			failures:     []error{errTransient, errTransient, errTransient, errTransient, errTransient},
+			wantErr:      errTransient,
+			wantAttempts: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			got, err := Retry(context.Background(), func(ctx context.Context) (int, error) {
+				attempts++
+				if attempts <= len(tt.failures) {
+					return 0, tt.failures[attempts-1]
+				}
+				return 42, nil
+			}, tt.policy)
+			if err != tt.wantErr {
+				t.Errorf("Retry() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got != 42 {
+				t.Errorf("Retry() = %v, want 42", got)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("Retry() made %d attempts, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRetryContext(t *testing.T) {
+	errTransient := errors.New("transient")
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := Retry(ctx, func(ctx context.Context) (int, error) {
+		return 0, errTransient
+	}, RetryPolicy{MaxAttempts: -1, InitialDelay: time.Hour, Jitter: NoJitter})
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, errTransient) {
+		t.Errorf("Retry() error = %v, want both %v and %v", err, context.DeadlineExceeded, errTransient)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialDelay: 100 * time.Millisecond, MaxDelay: time.Second, Multiplier: 2}.withDefaults()
+
+	policy.Jitter = NoJitter
+	for attempt, want := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		if got := policy.backoff(attempt+1, 0); got != want*time.Millisecond {
+			t.Errorf("backoff(%d) = %v, want %v", attempt+1, got, want*time.Millisecond)
+		}
+	}
+	if got := policy.backoff(500, 0); got != time.Second {
+		t.Errorf("backoff(500) = %v, want it capped to %v", got, time.Second)
+	}
+
+	policy.Jitter = FullJitter
+	for attempt := 1; attempt < 10; attempt++ {
+		if got := policy.backoff(attempt, 0); got < 0 || got >= time.Second {
+			t.Errorf("backoff(%d) with full jitter = %v, out of [0, 1s)", attempt, got)
+		}
+	}
+
+	policy.Jitter = DecorrelatedJitter
+	prev := time.Duration(0)
+	for attempt := 1; attempt < 10; attempt++ {
+		got := policy.backoff(attempt, prev)
+		upper := min(3*max(prev, policy.InitialDelay), policy.MaxDelay)
+		if got < policy.InitialDelay || got > upper {
+			t.Errorf("backoff(%d) with decorrelated jitter = %v, out of [%v, %v]", attempt, got, policy.InitialDelay, upper)
+		}
+		prev = got
+	}
+}
+
+func TestMapWithRetry(t *testing.T) {
+	errTransient := errors.New("transient")
+	calls := make([]int32, 3)
+	result, err := Map(context.Background(), []int{1, 2, 3}, func(ctx context.Context, n int) (int, error) {
+		//every item fails twice before succeeding.
+		if atomic.AddInt32(&calls[n-1], 1) <= 2 {
+			return 0, errTransient
+		}
+		return n * 2, nil
+	}, WithRetry(RetryPolicy{InitialDelay: time.Millisecond}))
+	if err != nil {
+		t.Fatalf("Map() unexpected error = %v", err)
+	}
+	for k, want := range []int{2, 4, 6} {
+		if result[k] != want {
+			t.Errorf("Expected %v, Got %v at Index: %d", want, result[k], k)
+		}
+	}
+}
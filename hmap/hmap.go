@@ -122,3 +122,17 @@ func (m *Map) String() string {
 	}
 	return strings.TrimRight(str, " ") + "]"
 }
+
+// GetOrPut returns the existing value for the key if present. Otherwise, it inserts the
+// given value and returns it. Second return parameter is true if the value was found,
+// false if inserted. Both steps happen atomically.
+func (m *Map) GetOrPut(key interface{}, value interface{}) (actual interface{}, found bool) {
+	m.Lock()
+	defer m.Unlock()
+	actual, found = m.items[key]
+	if !found {
+		m.items[key] = value
+		actual = value
+	}
+	return
+}
@@ -0,0 +1,329 @@
+package mysqldb
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/sanksons/gowraps/cipher"
+	"github.com/sanksons/gowraps/util"
+)
+
+// Define custom errors
+var ErrNoFieldCipher = fmt.Errorf("Encrypted field found but no FieldCipher configured")
+var ErrNoRowsToInsert = fmt.Errorf("Nothing to insert")
+var ErrNoSearchKey = fmt.Errorf("Deterministic field found but no SearchKey configured")
+
+// FieldCipher encrypts and decrypts struct fields tagged as encrypted, so that PII
+// columns are stored encrypted without any extra code around Execute and FetchRowsByQuery.
+//
+// Fields are mapped to columns by their lower cased name or by the name given in the
+// `db` tag, for scans as well as for Insert and Update; `db:"-"` leaves a field out.
+// Tag options control encryption, options of other libraries such as sqlx's omitempty
+// are ignored:
+//
+//	type User struct {
+//		ID    int    `db:"id,readonly"`              // scanned, never written by Insert/Update
+//		Name  string                                 // plain column "name"
+//		SSN   string `db:"ssn,encrypted"`            // randomized encryption
+//		Email string `db:"email,deterministic"`      // deterministic encryption, searchable by equality
+//		Notes string `db:"-"`                        // ignored
+//	}
+//
+// Encrypted columns should be VARBINARY or BLOB. The column name is bound to the
+// ciphertext, so a value copied into another column will fail to decrypt.
+type FieldCipher struct {
+	// Keyring encrypts fields tagged `encrypted`. Keys can be rotated, data keeps
+	// decrypting as long as the key it names is still in the ring.
+	Keyring *cipher.Keyring
+	// SearchKey encrypts fields tagged `deterministic`. The same value always gives the
+	// same ciphertext, which reveals equal values but allows WHERE col = ? lookups.
+	// Left as zero, deterministic fields fail with ErrNoSearchKey.
+	SearchKey [32]byte
+}
+
+// Searchable returns the value to compare a deterministic column against, e.g.
+//
+//	email, _ := fieldCipher.Searchable("email", "jane@example.com")
+//	conn.FetchRowByQuery("SELECT * FROM user WHERE email = ?", &user, email)
+func (this *FieldCipher) Searchable(column string, value string) ([]byte, error) {
+	if err := this.checkSearchKey(); err != nil {
+		return nil, err
+	}
+	return cipher.SealDeterministic(this.SearchKey, []byte(value), []byte(strings.ToLower(column)))
+}
+
+// checkSearchKey makes sure deterministic fields are never encrypted under the zero key.
+func (this *FieldCipher) checkSearchKey() error {
+	if this == nil {
+		return ErrNoFieldCipher
+	}
+	if this.SearchKey == [32]byte{} {
+		return ErrNoSearchKey
+	}
+	return nil
+}
+
+func (this *FieldCipher) encrypt(field fieldInfo, plain []byte) ([]byte, error) {
+	if this == nil {
+		return nil, ErrNoFieldCipher
+	}
+	if field.deterministic {
+		if err := this.checkSearchKey(); err != nil {
+			return nil, err
+		}
+		return cipher.SealDeterministic(this.SearchKey, plain, []byte(field.column))
+	}
+	if this.Keyring == nil {
+		return nil, ErrNoFieldCipher
+	}
+	return this.Keyring.Encrypt(plain, []byte(field.column))
+}
+
+func (this *FieldCipher) decrypt(field fieldInfo, data []byte) ([]byte, error) {
+	if this == nil {
+		return nil, ErrNoFieldCipher
+	}
+	if field.deterministic {
+		if err := this.checkSearchKey(); err != nil {
+			return nil, err
+		}
+		return cipher.OpenDeterministic(this.SearchKey, data, []byte(field.column))
+	}
+	if this.Keyring == nil {
+		return nil, ErrNoFieldCipher
+	}
+	return this.Keyring.Decrypt(data, []byte(field.column))
+}
+
+// Holds the mapping between a struct field and a column.
+type fieldInfo struct {
+	index         int
+	column        string
+	encrypted     bool
+	deterministic bool
+	readonly      bool
+}
+
+// Types that can be stored in encrypted fields.
+var (
+	typeString    = reflect.TypeOf("")
+	typeBytes     = reflect.TypeOf([]byte(nil))
+	typeStringPtr = reflect.TypeOf((*string)(nil))
+)
+
+// getFieldsInfo returns the mapped fields of the struct type t, in declaration order.
+func getFieldsInfo(t reflect.Type) ([]fieldInfo, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Expected Struct type, Got %v instead", t.Kind())
+	}
+	fields := make([]fieldInfo, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		info := fieldInfo{index: i, column: strings.ToLower(f.Name)}
+		if tag, ok := f.Tag.Lookup("db"); ok {
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" {
+				continue
+			}
+			if parts[0] != "" {
+				info.column = strings.ToLower(parts[0])
+			}
+			for _, opt := range parts[1:] {
+				switch strings.TrimSpace(opt) {
+				case "encrypted":
+					info.encrypted = true
+				case "deterministic":
+					info.encrypted, info.deterministic = true, true
+				case "readonly":
+					info.readonly = true
+				}
+			}
+		}
+		if info.encrypted && f.Type != typeString && f.Type != typeBytes && f.Type != typeStringPtr {
+			return nil, fmt.Errorf("Field %s of type %v cannot be encrypted, use string, *string or []byte", f.Name, f.Type)
+		}
+		fields = append(fields, info)
+	}
+	return fields, nil
+}
+
+// fieldBytes returns the plain bytes of an encryptable field. Second return parameter
+// is false if the field holds a NULL value (nil pointer or nil slice).
+func fieldBytes(v reflect.Value) ([]byte, bool) {
+	switch v.Type() {
+	case typeString:
+		return []byte(v.String()), true
+	case typeBytes:
+		return v.Bytes(), !v.IsNil()
+	case typeStringPtr:
+		if v.IsNil() {
+			return nil, false
+		}
+		return []byte(v.Elem().String()), true
+	}
+	return nil, false
+}
+
+// setFieldBytes stores decrypted bytes in an encryptable field. A nil data stands for NULL.
+func setFieldBytes(v reflect.Value, data []byte) {
+	switch v.Type() {
+	case typeString:
+		v.SetString(string(data))
+	case typeBytes:
+		v.SetBytes(data)
+	case typeStringPtr:
+		if data == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return
+		}
+		s := string(data)
+		v.Set(reflect.ValueOf(&s))
+	}
+}
+
+// columnValue returns the value to send to the database for field of the struct row,
+// encrypting it if needed.
+func (this *FieldCipher) columnValue(field fieldInfo, row reflect.Value) (interface{}, error) {
+	v := row.Field(field.index)
+	if !field.encrypted {
+		return v.Interface(), nil
+	}
+	plain, ok := fieldBytes(v)
+	if !ok {
+		return nil, nil
+	}
+	return this.encrypt(field, plain)
+}
+
+// structValues drills down holder to the struct values it contains. Holder can be a
+// struct, a pointer to struct, or a slice (or pointer to slice) of structs.
+func structValues(holder interface{}) ([]reflect.Value, reflect.Type, error) {
+	v := reflect.ValueOf(holder)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil, fmt.Errorf("The supplied pointer points to blackhole")
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return []reflect.Value{v}, v.Type(), nil
+	case reflect.Slice:
+		t := v.Type().Elem()
+		if t.Kind() != reflect.Struct {
+			return nil, nil, fmt.Errorf("Expected slice of structs but didn't got it.")
+		}
+		rows := make([]reflect.Value, v.Len())
+		for i := range rows {
+			rows[i] = v.Index(i)
+		}
+		return rows, t, nil
+	}
+	return nil, nil, fmt.Errorf("Its neither a struct nor slice of structs")
+}
+
+// Insert inserts one or more rows into table. rows can be a struct, a pointer to struct,
+// or a slice of structs for a multi row insert. Fields tagged `encrypted` or
+// `deterministic` are encrypted with the configured FieldCipher, fields tagged `readonly`
+// or `-` are left out.
+//
+// Usage:
+//
+//	conn := pool.GetConnection()
+//	conn.Insert("user", User{Name: "Jane", SSN: "123-45-6789"})
+func (this *MySqlConnection) Insert(table string, rows interface{}) (sql.Result, error) {
+	query, args, err := this.fieldCipher.insertQuery(table, rows)
+	if err != nil {
+		return nil, err
+	}
+	return this.Execute(query, args...)
+}
+
+// insertQuery builds the query and arguments of Insert.
+func (this *FieldCipher) insertQuery(table string, rows interface{}) (string, []interface{}, error) {
+	values, t, err := structValues(rows)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(values) == 0 {
+		return "", nil, ErrNoRowsToInsert
+	}
+	fields, err := getFieldsInfo(t)
+	if err != nil {
+		return "", nil, err
+	}
+	var columns []string
+	for _, field := range fields {
+		if !field.readonly {
+			columns = append(columns, field.column)
+		}
+	}
+	sets := make([][]interface{}, 0, len(values))
+	var args []interface{}
+	for _, row := range values {
+		set := make([]interface{}, 0, len(columns))
+		for _, field := range fields {
+			if field.readonly {
+				continue
+			}
+			value, err := this.columnValue(field, row)
+			if err != nil {
+				return "", nil, err
+			}
+			set = append(set, value)
+		}
+		sets = append(sets, set)
+		args = append(args, set...)
+	}
+	return util.GetMultiInsertQuery(table, columns, sets), args, nil
+}
+
+// Update writes all mapped fields of row to the rows of table matched by where,
+// encrypting fields as Insert does. Fields tagged `readonly` or `-` are left untouched.
+//
+// Usage:
+//
+//	conn := pool.GetConnection()
+//	conn.Update("user", user, "id = ?", user.ID)
+func (this *MySqlConnection) Update(table string, row interface{}, where string, args ...interface{}) (sql.Result, error) {
+	query, params, err := this.fieldCipher.updateQuery(table, row, where, args)
+	if err != nil {
+		return nil, err
+	}
+	return this.Execute(query, params...)
+}
+
+// updateQuery builds the query and arguments of Update, the where arguments last.
+func (this *FieldCipher) updateQuery(table string, row interface{}, where string, args []interface{}) (string, []interface{}, error) {
+	values, t, err := structValues(row)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(values) != 1 {
+		return "", nil, fmt.Errorf("Expected a single struct to update, got %d", len(values))
+	}
+	fields, err := getFieldsInfo(t)
+	if err != nil {
+		return "", nil, err
+	}
+	var sets []string
+	var params []interface{}
+	for _, field := range fields {
+		if field.readonly {
+			continue
+		}
+		value, err := this.columnValue(field, values[0])
+		if err != nil {
+			return "", nil, err
+		}
+		sets = append(sets, field.column+" = ?")
+		params = append(params, value)
+	}
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s", table, strings.Join(sets, ", "), where)
+	return query, append(params, args...), nil
+}
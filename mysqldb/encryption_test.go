@@ -0,0 +1,242 @@
+package mysqldb
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sanksons/gowraps/cipher"
+)
+
+type testCustomer struct {
+	ID     int    `db:"id,readonly"`
+	Name   string `db:",omitempty"`
+	SSN    string `db:"ssn,encrypted"`
+	Email  string `db:"Email,deterministic"`
+	Notes  string `db:"-"`
+	Phone  *string
+	Avatar []byte `db:"avatar,encrypted"`
+	secret string
+}
+
+func createTestFieldCipher(t *testing.T) *FieldCipher {
+	keyring := cipher.NewKeyring()
+	if err := keyring.Add(1, [32]byte{1}); err != nil {
+		t.Fatalf("Keyring.Add() unexpected error = %v", err)
+	}
+	if err := keyring.SetActive(1); err != nil {
+		t.Fatalf("Keyring.SetActive() unexpected error = %v", err)
+	}
+	return &FieldCipher{Keyring: keyring, SearchKey: [32]byte{2}}
+}
+
+func TestGetFieldsInfo(t *testing.T) {
+	fields, err := getFieldsInfo(reflect.TypeOf(testCustomer{}))
+	if err != nil {
+		t.Fatalf("getFieldsInfo() unexpected error = %v", err)
+	}
+
+	expected := []fieldInfo{
+		{index: 0, column: "id", readonly: true},
+		{index: 1, column: "name"},
+		{index: 2, column: "ssn", encrypted: true},
+		{index: 3, column: "email", encrypted: true, deterministic: true},
+		{index: 5, column: "phone"},
+		{index: 6, column: "avatar", encrypted: true},
+	}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Errorf("getFieldsInfo() = %+v, want %+v", fields, expected)
+	}
+}
+
+func TestGetFieldsInfoErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input reflect.Type
+	}{
+		{
+			name:  "Not a struct",
+			input: reflect.TypeOf(""),
+		},
+		{
+			name: "Encrypted int",
+			input: reflect.TypeOf(struct {
+				Age int `db:"age,encrypted"`
+			}{}),
+		},
+		{
+			name: "Deterministic *int",
+			input: reflect.TypeOf(struct {
+				Age *int `db:"age,deterministic"`
+			}{}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := getFieldsInfo(tt.input); err == nil {
+				t.Errorf("getFieldsInfo() expected error but got none")
+			}
+		})
+	}
+}
+
+func TestFieldBytesRoundTrip(t *testing.T) {
+	name := "Jane"
+
+	tests := []struct {
+		name     string
+		input    interface{}
+		expected []byte
+		wantNull bool
+	}{
+		{name: "String", input: "Jane", expected: []byte("Jane")},
+		{name: "Empty string", input: "", expected: []byte{}},
+		{name: "String pointer", input: &name, expected: []byte("Jane")},
+		{name: "Nil string pointer", input: (*string)(nil), wantNull: true},
+		{name: "Bytes", input: []byte{0, 1, 2}, expected: []byte{0, 1, 2}},
+		{name: "Nil bytes", input: []byte(nil), wantNull: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, ok := fieldBytes(reflect.ValueOf(tt.input))
+			if ok == tt.wantNull || !bytes.Equal(data, tt.expected) {
+				t.Fatalf("fieldBytes() = %v, %v, want %v, NULL %v", data, ok, tt.expected, tt.wantNull)
+			}
+
+			holder := reflect.New(reflect.TypeOf(tt.input)).Elem()
+			if !ok {
+				data = nil
+			}
+			setFieldBytes(holder, data)
+			if !reflect.DeepEqual(holder.Interface(), tt.input) {
+				t.Errorf("setFieldBytes() = %#v, want %#v", holder.Interface(), tt.input)
+			}
+		})
+	}
+}
+
+func TestColumnValue(t *testing.T) {
+	fc := createTestFieldCipher(t)
+	fields, _ := getFieldsInfo(reflect.TypeOf(testCustomer{}))
+	row := reflect.ValueOf(testCustomer{Name: "Jane", SSN: "123-45-6789", Email: "jane@example.com"})
+
+	// Plain columns are passed as is, with or without a FieldCipher.
+	for _, c := range []*FieldCipher{fc, nil} {
+		if value, err := c.columnValue(fields[1], row); err != nil || value != "Jane" {
+			t.Errorf("columnValue() = %v, %v, want Jane", value, err)
+		}
+	}
+
+	value, err := fc.columnValue(fields[2], row)
+	if err != nil {
+		t.Fatalf("columnValue() unexpected error = %v", err)
+	}
+	plain, err := fc.decrypt(fields[2], value.([]byte))
+	if err != nil || string(plain) != "123-45-6789" {
+		t.Errorf("decrypt() = %s, %v, want 123-45-6789", plain, err)
+	}
+
+	// Deterministic columns can be looked up with Searchable.
+	value, _ = fc.columnValue(fields[3], row)
+	search, _ := fc.Searchable("Email", "jane@example.com")
+	if !bytes.Equal(value.([]byte), search) {
+		t.Errorf("columnValue() = %x, want Searchable() = %x", value, search)
+	}
+
+	// NULL stays NULL.
+	if value, err := fc.columnValue(fields[4], row); err != nil || value != (*string)(nil) {
+		t.Errorf("columnValue() of NULL = %v, %v, want nil", value, err)
+	}
+	if value, err := fc.columnValue(fields[5], row); err != nil || value != nil {
+		t.Errorf("columnValue() of NULL encrypted = %v, %v, want nil", value, err)
+	}
+
+	if _, err := (*FieldCipher)(nil).columnValue(fields[2], row); !errors.Is(err, ErrNoFieldCipher) {
+		t.Errorf("columnValue() without FieldCipher error = %v, want %v", err, ErrNoFieldCipher)
+	}
+	if _, err := (&FieldCipher{}).columnValue(fields[2], row); !errors.Is(err, ErrNoFieldCipher) {
+		t.Errorf("columnValue() without Keyring error = %v, want %v", err, ErrNoFieldCipher)
+	}
+}
+
+func TestFieldCipherWithoutSearchKey(t *testing.T) {
+	fc := createTestFieldCipher(t)
+	fc.SearchKey = [32]byte{}
+	fields, _ := getFieldsInfo(reflect.TypeOf(testCustomer{}))
+	row := reflect.ValueOf(testCustomer{SSN: "123-45-6789", Email: "jane@example.com"})
+
+	// Randomized fields only need the Keyring.
+	if _, err := fc.columnValue(fields[2], row); err != nil {
+		t.Errorf("columnValue() unexpected error = %v", err)
+	}
+	if _, err := fc.columnValue(fields[3], row); !errors.Is(err, ErrNoSearchKey) {
+		t.Errorf("columnValue() error = %v, want %v", err, ErrNoSearchKey)
+	}
+	if _, err := fc.Searchable("email", "jane@example.com"); !errors.Is(err, ErrNoSearchKey) {
+		t.Errorf("Searchable() error = %v, want %v", err, ErrNoSearchKey)
+	}
+	sealed, _ := cipher.SealDeterministic([32]byte{}, []byte("jane@example.com"), []byte("email"))
+	if _, err := fc.decrypt(fields[3], sealed); !errors.Is(err, ErrNoSearchKey) {
+		t.Errorf("decrypt() error = %v, want %v", err, ErrNoSearchKey)
+	}
+	if _, err := (*FieldCipher)(nil).Searchable("email", "jane@example.com"); !errors.Is(err, ErrNoFieldCipher) {
+		t.Errorf("Searchable() without FieldCipher error = %v, want %v", err, ErrNoFieldCipher)
+	}
+}
+
+func TestInsertQuery(t *testing.T) {
+	fc := createTestFieldCipher(t)
+	rows := []testCustomer{
+		{ID: 1, Name: "Jane", SSN: "1", Email: "jane@example.com", Notes: "skipped"},
+		{ID: 2, Name: "John", SSN: "2", Email: "john@example.com"},
+	}
+
+	query, args, err := fc.insertQuery("customer", rows)
+	if err != nil {
+		t.Fatalf("insertQuery() unexpected error = %v", err)
+	}
+	expected := "INSERT INTO customer (name,ssn,email,phone,avatar) VALUES (?, ?, ?, ?, ?),(?, ?, ?, ?, ?)"
+	if query != expected {
+		t.Errorf("insertQuery() = %v, want %v", query, expected)
+	}
+	if len(args) != 10 || args[0] != "Jane" || args[5] != "John" {
+		t.Fatalf("insertQuery() args = %v, want name first for each row", args)
+	}
+	if plain, err := fc.decrypt(fieldInfo{column: "ssn", encrypted: true}, args[6].([]byte)); err != nil || string(plain) != "2" {
+		t.Errorf("insertQuery() args[6] decrypts to %s, %v, want 2", plain, err)
+	}
+
+	if _, _, err := fc.insertQuery("customer", []testCustomer{}); !errors.Is(err, ErrNoRowsToInsert) {
+		t.Errorf("insertQuery() error = %v, want %v", err, ErrNoRowsToInsert)
+	}
+}
+
+func TestUpdateQuery(t *testing.T) {
+	fc := createTestFieldCipher(t)
+	row := &testCustomer{ID: 7, Name: "Jane", SSN: "1", Email: "jane@example.com"}
+
+	query, args, err := fc.updateQuery("customer", row, "id = ?", []interface{}{7})
+	if err != nil {
+		t.Fatalf("updateQuery() unexpected error = %v", err)
+	}
+	expected := "UPDATE customer SET name = ?, ssn = ?, email = ?, phone = ?, avatar = ? WHERE id = ?"
+	if query != expected {
+		t.Errorf("updateQuery() = %v, want %v", query, expected)
+	}
+	if len(args) != 6 || args[0] != "Jane" || args[5] != 7 {
+		t.Errorf("updateQuery() args = %v, want fields then where args", args)
+	}
+
+	_, _, err = (*FieldCipher)(nil).updateQuery("customer", row, "id = ?", nil)
+	if !errors.Is(err, ErrNoFieldCipher) {
+		t.Errorf("updateQuery() without FieldCipher error = %v, want %v", err, ErrNoFieldCipher)
+	}
+	_, _, err = fc.updateQuery("customer", []testCustomer{*row, *row}, "id = ?", nil)
+	if err == nil || !strings.Contains(err.Error(), "single struct") {
+		t.Errorf("updateQuery() of 2 rows error = %v, want single struct error", err)
+	}
+}
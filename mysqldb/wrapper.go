@@ -2,6 +2,7 @@ package mysqldb
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
@@ -19,7 +20,7 @@ func Initiate(config MySqlConfig) (*MySqlPool, error) {
 	}
 	db.SetMaxIdleConns(config.MaxIdleConnections)
 	db.SetMaxOpenConns(config.MaxOpenConnections)
-	return &MySqlPool{db: db}, nil
+	return &MySqlPool{db: db, fieldCipher: config.FieldCipher}, nil
 }
 
 // Define custom errors
@@ -34,6 +35,8 @@ type MySqlConfig struct {
 	DBName             string
 	MaxOpenConnections int
 	MaxIdleConnections int
+	// Optional, needed to read and write struct fields tagged as encrypted.
+	FieldCipher *FieldCipher
 }
 
 // converts the configuration to the format understood by go sql driver.
@@ -52,7 +55,8 @@ func (this *MySqlConfig) FormatDSN() string {
 // A pool maintains a set of connections.
 // Bydefault no connection is created. The connection is created only when query is fired.
 type MySqlPool struct {
-	db *sql.DB
+	db          *sql.DB
+	fieldCipher *FieldCipher
 }
 
 // Ping checks if we can still access the database.
@@ -62,7 +66,7 @@ func (this *MySqlPool) Ping() error {
 
 // GetConnection returns a fresh *MySqlConnection object which can be further used to perform queries.
 func (this *MySqlPool) GetConnection() *MySqlConnection {
-	connection := &MySqlConnection{db: this.db}
+	connection := &MySqlConnection{db: this.db, fieldCipher: this.fieldCipher}
 	return connection
 }
 
@@ -73,9 +77,10 @@ func (this *MySqlPool) Close() error {
 
 // On a broader level this can be seen as a Mysql connection.
 type MySqlConnection struct {
-	db   *sql.DB
-	tx   *sql.Tx
-	stmt *sql.Stmt
+	db          *sql.DB
+	tx          *sql.Tx
+	stmt        *sql.Stmt
+	fieldCipher *FieldCipher
 }
 
 // A dummy function which pretends to close the MySqlConnection
@@ -176,7 +181,7 @@ func (this *MySqlConnection) FetchRowsByQuery(query string, holder interface{},
 	if err != nil {
 		return err
 	}
-	mysqlRows := MySqlRows{rows: rows}
+	mysqlRows := MySqlRows{rows: rows, fieldCipher: this.fieldCipher}
 	return mysqlRows.scan(holder)
 }
 
@@ -238,7 +243,8 @@ func (this *MySqlConnection) resetTx() {
 
 // Contains rows object returned from db
 type MySqlRows struct {
-	rows *sql.Rows
+	rows        *sql.Rows
+	fieldCipher *FieldCipher
 }
 
 // Scans the data from sql.Rows into the holder provided
@@ -249,6 +255,10 @@ type MySqlRows struct {
 //	or
 //
 // Pointer to slice of structs (*[]struct).
+//
+// Columns are matched to fields by the lower cased field name, or by the name given in
+// the field's `db` tag; fields tagged `db:"-"` are not filled. Fields tagged as encrypted
+// are decrypted with the configured FieldCipher.
 func (this *MySqlRows) scan(holder interface{}) error {
 
 	defer this.rows.Close()
@@ -264,7 +274,6 @@ func (this *MySqlRows) scan(holder interface{}) error {
 		return fmt.Errorf("The supplied pointer points to blackhole")
 	}
 	child := reflectObj.GetChild()
-	var structInfo map[string]int
 	var err error
 
 	var childStruct *reflexer.ReflectObj
@@ -290,10 +299,14 @@ func (this *MySqlRows) scan(holder interface{}) error {
 		return fmt.Errorf("Could not get columns Info: %s", err.Error())
 	}
 	//Get info about struct
-	structInfo, err = reflexer.GetInfoAboutFieldsofStruct(*childStruct)
+	fields, err := getFieldsInfo(childStruct.T)
 	if err != nil {
 		return fmt.Errorf("Scan Failed: %s", err.Error())
 	}
+	structInfo := make(map[string]fieldInfo, len(fields))
+	for _, field := range fields {
+		structInfo[field.column] = field
+	}
 	var iteration int
 	var structList []reflect.Value
 	for this.rows.Next() {
@@ -310,21 +323,43 @@ func (this *MySqlRows) scan(holder interface{}) error {
 			rowStruct = childStruct.V
 		}
 		var final []interface{}
-		for _, col := range columns {
+		encrypted := make(map[int]*[]byte)
+		for k, col := range columns {
 			col = strings.ToLower(col)
-			index, ok := structInfo[col]
+			field, ok := structInfo[col]
 			if !ok {
 				var skipVal string = ""
 				pointerSkipval := &skipVal
 				final = append(final, &pointerSkipval)
 				continue //skip columns not found in struct
 			}
-			final = append(final, rowStruct.FieldByIndex([]int{index}).Addr().Interface())
+			if field.encrypted {
+				//scan raw ciphertext, decrypted into the field below.
+				raw := new([]byte)
+				encrypted[k] = raw
+				final = append(final, raw)
+				continue
+			}
+			final = append(final, rowStruct.FieldByIndex([]int{field.index}).Addr().Interface())
 		}
 		err = this.rows.Scan(final...)
 		if err != nil {
 			return err
 		}
+		for k, raw := range encrypted {
+			field := structInfo[strings.ToLower(columns[k])]
+			var plain []byte
+			if *raw != nil {
+				plain, err = this.fieldCipher.decrypt(field, *raw)
+				if err != nil {
+					return fmt.Errorf("Could not decrypt column %s: %s", field.column, err.Error())
+				}
+				if plain == nil {
+					plain = []byte{}
+				}
+			}
+			setFieldBytes(rowStruct.Field(field.index), plain)
+		}
 		if isMulti {
 			structList = append(structList, rowStruct)
 		}
@@ -342,3 +377,15 @@ func (this *MySqlRows) getColumns() (columns []string, err error) {
 	columns, err = this.rows.Columns()
 	return
 }
+
+// IsRetryable reports whether err is a transient MySQL error, a deadlock or a lock wait
+// timeout, after which the transaction can be retried as is.
+// Usable as Retryable of a concurrency.RetryPolicy.
+func IsRetryable(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	//1213: ER_LOCK_DEADLOCK, 1205: ER_LOCK_WAIT_TIMEOUT
+	return mysqlErr.Number == 1213 || mysqlErr.Number == 1205
+}
@@ -0,0 +1,275 @@
+package timer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule tells when a job runs next.
+type Schedule interface {
+	// Next returns the first run time strictly after t, in t's location. It returns the
+	// zero time if there is none within the next 5 years.
+	Next(t time.Time) time.Time
+}
+
+// ParseCron parses a schedule. It accepts
+//   - standard cron expressions of 5 fields: minute, hour, day of month, month and day
+//     of week, e.g. "30 2 * * MON-FRI"
+//   - cron expressions of 6 fields, starting with the second, e.g. "*/10 * * * * *"
+//   - "@every <duration>", e.g. "@every 5m", the duration being at least a second
+//   - the descriptors @yearly (or @annually), @monthly, @weekly, @daily (or @midnight)
+//     and @hourly
+//
+// Fields take values, ranges (1-5), steps (*/15, 0-30/10) and lists of them (1,15,30).
+// Months and days of week also take their 3 letter English name; Sunday is 0 or 7.
+// "?" is same as "*" in day fields. As in cron, a job runs when either day field matches
+// if both are restricted; a day field starting with "*", such as "*/2", is not.
+func ParseCron(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+		if d < time.Second {
+			return nil, fmt.Errorf("invalid schedule %q: interval must be at least 1s", spec)
+		}
+		return everySchedule{interval: d}, nil
+	}
+	if expr, ok := descriptors[spec]; ok {
+		spec = expr
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("invalid schedule %q: expected 5 or 6 fields, got %d", spec, len(fields))
+	}
+	var s cronSchedule
+	sets := []*uint64{&s.second, &s.minute, &s.hour, &s.dom, &s.month, &s.dow}
+	for k, field := range fields {
+		bits, err := parseField(field, cronFields[k])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %s: %w", spec, cronFields[k].name, err)
+		}
+		*sets[k] = bits
+	}
+	// Sunday can be written 7.
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domAny = isDayAny(fields[3])
+	s.dowAny = isDayAny(fields[5])
+	return s, nil
+}
+
+// descriptors are the cron expressions of the @ shortcuts.
+var descriptors = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+// cronField describes the values a field of a cron expression can take.
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var cronFields = []cronField{
+	{name: "second", min: 0, max: 59},
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}},
+	{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}},
+}
+
+// isAny tells whether a field matches every value.
+func isAny(field string) bool {
+	return field == "*" || field == "?"
+}
+
+// isDayAny tells whether a day field leaves the day unrestricted. As in Vixie cron, a
+// field starting with "*" does even with a step, so "*/2" combines with the other day
+// field instead of running on either.
+func isDayAny(field string) bool {
+	return strings.HasPrefix(field, "*") || field == "?"
+}
+
+// parseField returns the set of values of field, as a bit per value.
+func parseField(field string, f cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			var err error
+			rng = part[:i]
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+		}
+
+		low, high := f.min, f.max
+		switch {
+		case isAny(rng):
+		case strings.Contains(rng, "-"):
+			bounds := strings.SplitN(rng, "-", 2)
+			var err error
+			if low, err = parseValue(bounds[0], f); err != nil {
+				return 0, err
+			}
+			if high, err = parseValue(bounds[1], f); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range %q", rng)
+			}
+		default:
+			var err error
+			if low, err = parseValue(rng, f); err != nil {
+				return 0, err
+			}
+			// "5/15" starts at 5 and steps up to the maximum.
+			if step == 1 {
+				high = low
+			}
+		}
+		for v := low; v <= high; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// parseValue returns the value of a single number or name of field f.
+func parseValue(value string, f cronField) (int, error) {
+	if v, ok := f.names[strings.ToLower(value)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", value)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", v, f.min, f.max)
+	}
+	return v, nil
+}
+
+// cronSchedule is a parsed cron expression, every field being a set of values with a
+// bit per value.
+type cronSchedule struct {
+	second, minute, hour, dom, month, dow uint64
+	// domAny and dowAny tell whether the day fields were left unrestricted.
+	domAny, dowAny bool
+}
+
+// Next implements Schedule. It walks from t to the next matching time, field by field,
+// skipping whole months, days, hours and minutes that do not match.
+//
+// When clocks move forward, times in the skipped hour never match. When they move back,
+// times of the repeated hour match only once, at the earlier offset, so a job set at
+// 1:30 runs once that night; schedules matching every hour keep running on elapsed time
+// instead, as in cron.
+func (s cronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Second).Add(time.Second)
+	limit := t.Year() + 5
+
+	for t.Year() <= limit {
+		year, month, day := t.Date()
+		switch {
+		case s.month&(1<<uint(month)) == 0:
+			t = time.Date(year, month+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(t):
+			t = time.Date(year, month, day+1, 0, 0, 0, 0, loc)
+		// Hours and minutes move on elapsed time rather than wall clock, so that t never
+		// goes backwards when clocks are set back.
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = t.Add(time.Hour - time.Duration(t.Minute())*time.Minute - time.Duration(t.Second())*time.Second)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute - time.Duration(t.Second())*time.Second)
+		case s.second&(1<<uint(t.Second())) == 0:
+			t = t.Add(time.Second)
+		case s.hour != everyHour:
+			if end, ok := repeatedUntil(t); ok {
+				t = end
+				continue
+			}
+			return t
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// everyHour is the hour field matching every hour.
+const everyHour = 1<<24 - 1
+
+// repeatedUntil tells whether the wall clock time of t already happened earlier that
+// day at another offset, clocks having been set back since. It then returns the end
+// of the repeated period, the first time after t not repeated.
+func repeatedUntil(t time.Time) (time.Time, bool) {
+	start, _ := t.ZoneBounds()
+	if start.IsZero() {
+		return time.Time{}, false
+	}
+	_, before := start.Add(-time.Second).Zone()
+	_, after := t.Zone()
+	shift := time.Duration(before-after) * time.Second
+	if shift <= 0 || t.Sub(start) >= shift {
+		return time.Time{}, false
+	}
+	return start.Add(shift), true
+}
+
+// dayMatches tells whether the day of t matches the day of month and day of week fields.
+func (s cronSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// everySchedule runs at a fixed interval, see "@every" in ParseCron.
+type everySchedule struct {
+	interval time.Duration
+}
+
+// Next implements Schedule, rounding to the second.
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Truncate(time.Second).Add(s.interval.Truncate(time.Second))
+}
+
+// NextRun returns the next run time of spec after the current time in the given time
+// zone, UTC if zone is empty, as GetCurrentTime.
+func NextRun(spec string, zone string) (time.Time, error) {
+	schedule, err := ParseCron(spec)
+	if err != nil {
+		return time.Time{}, err
+	}
+	now, err := GetCurrentTime(zone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return schedule.Next(now), nil
+}
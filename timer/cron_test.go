@@ -0,0 +1,183 @@
+package timer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	date := func(year int, month time.Month, day, hour, min, sec int) time.Time {
+		return time.Date(year, month, day, hour, min, sec, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		spec     string
+		from     time.Time
+		expected time.Time
+	}{
+		{
+			name:     "Every 15 minutes",
+			spec:     "*/15 * * * *",
+			from:     date(2024, 1, 1, 10, 7, 30),
+			expected: date(2024, 1, 1, 10, 15, 0),
+		},
+		{
+			name:     "Daily, already past today",
+			spec:     "30 2 * * *",
+			from:     date(2024, 1, 1, 3, 0, 0),
+			expected: date(2024, 1, 2, 2, 30, 0),
+		},
+		{
+			name:     "Week days by name",
+			spec:     "0 9 * * MON-FRI",
+			from:     date(2024, 1, 6, 12, 0, 0), // Saturday
+			expected: date(2024, 1, 8, 9, 0, 0),
+		},
+		{
+			name:     "Sunday as 7",
+			spec:     "0 0 * * 7",
+			from:     date(2024, 1, 1, 0, 0, 0),
+			expected: date(2024, 1, 7, 0, 0, 0),
+		},
+		{
+			name:     "Either day field matches",
+			spec:     "0 0 1,15 * 1",
+			from:     date(2024, 1, 2, 0, 0, 0), // Tuesday
+			expected: date(2024, 1, 8, 0, 0, 0),
+		},
+		{
+			name:     "Day of month step with day of week",
+			spec:     "0 0 */2 * MON",
+			from:     date(2024, 1, 1, 0, 0, 0), // Monday
+			expected: date(2024, 1, 15, 0, 0, 0),
+		},
+		{
+			name:     "Seconds field",
+			spec:     "*/10 * * * * *",
+			from:     date(2024, 1, 1, 10, 0, 5),
+			expected: date(2024, 1, 1, 10, 0, 10),
+		},
+		{
+			name:     "Step from a value",
+			spec:     "5/20 * * * *",
+			from:     date(2024, 1, 1, 10, 26, 0),
+			expected: date(2024, 1, 1, 10, 45, 0),
+		},
+		{
+			name:     "Leap day",
+			spec:     "0 0 29 feb *",
+			from:     date(2024, 3, 1, 0, 0, 0),
+			expected: date(2028, 2, 29, 0, 0, 0),
+		},
+		{
+			name:     "Impossible date",
+			spec:     "0 0 30 2 *",
+			from:     date(2024, 1, 1, 0, 0, 0),
+			expected: time.Time{},
+		},
+		{
+			name:     "Daily descriptor",
+			spec:     "@daily",
+			from:     date(2024, 1, 1, 10, 0, 0),
+			expected: date(2024, 1, 2, 0, 0, 0),
+		},
+		{
+			name:     "Every 5 minutes",
+			spec:     "@every 5m",
+			from:     date(2024, 1, 1, 10, 0, 30).Add(500 * time.Millisecond),
+			expected: date(2024, 1, 1, 10, 5, 30),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCron(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseCron() unexpected error = %v", err)
+			}
+			if result := schedule.Next(tt.from); !result.Equal(tt.expected) {
+				t.Errorf("Next() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	specs := []string{
+		"* * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"* * * FOO *",
+		"@every 100ms",
+		"@every often",
+	}
+
+	for _, spec := range specs {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("ParseCron(%q) expected error but got none", spec)
+		}
+	}
+}
+
+func TestCronTimezone(t *testing.T) {
+	ny, err := loadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("Failed to load New York location: %v", err)
+	}
+	schedule, _ := ParseCron("30 2 * * *")
+
+	// 2:30 does not exist on the day clocks move forward.
+	result := schedule.Next(time.Date(2024, 3, 9, 3, 0, 0, 0, ny))
+	if expected := time.Date(2024, 3, 11, 2, 30, 0, 0, ny); !result.Equal(expected) {
+		t.Errorf("Next() = %v, want %v", result, expected)
+	}
+	// Run times are in the zone of the given time.
+	result = schedule.Next(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC).In(ny))
+	if expected := time.Date(2024, 6, 2, 6, 30, 0, 0, time.UTC); !result.Equal(expected) {
+		t.Errorf("Next() = %v, want %v", result, expected)
+	}
+
+	// 1:00 to 2:00 happens twice on the day clocks move back.
+	tests := []struct {
+		spec     string
+		from     time.Time
+		expected []string
+	}{
+		{
+			spec:     "30 1 * * *",
+			from:     time.Date(2026, 11, 1, 0, 0, 0, 0, ny),
+			expected: []string{"2026-11-01 01:30 EDT", "2026-11-02 01:30 EST"},
+		},
+		{
+			spec:     "*/30 1 * * *",
+			from:     time.Date(2026, 11, 1, 0, 0, 0, 0, ny),
+			expected: []string{"2026-11-01 01:00 EDT", "2026-11-01 01:30 EDT", "2026-11-02 01:00 EST"},
+		},
+		{
+			spec:     "0 * * * *",
+			from:     time.Date(2026, 11, 1, 0, 30, 0, 0, ny),
+			expected: []string{"2026-11-01 01:00 EDT", "2026-11-01 01:00 EST", "2026-11-01 02:00 EST"},
+		},
+	}
+	for _, tt := range tests {
+		schedule, _ := ParseCron(tt.spec)
+		result := tt.from
+		for _, expected := range tt.expected {
+			result = schedule.Next(result)
+			if got := result.Format("2006-01-02 15:04 MST"); got != expected {
+				t.Errorf("Next() of %q = %v, want %v", tt.spec, got, expected)
+			}
+		}
+	}
+
+	next, err := NextRun("@hourly", "")
+	if err != nil || next.Location().String() != "UTC" || next.Minute() != 0 {
+		t.Errorf("NextRun() = %v, %v, want the next hour in UTC", next, err)
+	}
+	if _, err := NextRun("@hourly", "Invalid/Timezone"); err == nil {
+		t.Errorf("NextRun() expected error but got none")
+	}
+}
@@ -0,0 +1,322 @@
+package timer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sanksons/gowraps/concurrency"
+)
+
+var (
+	// ErrJobExists is returned by Scheduler.Add for a name already in use.
+	ErrJobExists = errors.New("job already exists")
+	// ErrSchedulerStopped is returned by Scheduler.Start once the scheduler was stopped.
+	ErrSchedulerStopped = errors.New("scheduler stopped")
+)
+
+// Clock tells the time to a Scheduler. Tests can replace it to control time.
+type Clock interface {
+	Now() time.Time
+	// After sends the time on the returned channel once d elapsed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is the Clock of the time package.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Overlap tells what to do when a job is due while its previous run is still going.
+type Overlap int
+
+const (
+	// OverlapSkip drops the run, the job runs again at its next scheduled time.
+	OverlapSkip Overlap = iota
+	// OverlapQueue starts the run once the previous one finished. Runs queue up one
+	// after another, so a job always slower than its schedule falls further behind.
+	OverlapQueue
+	// OverlapAllow starts the run right away, alongside the previous one.
+	OverlapAllow
+)
+
+// SchedulerConfig configures a Scheduler.
+type SchedulerConfig struct {
+	// Zone is the time zone schedules are evaluated in, UTC if empty, see GetCurrentTime.
+	Zone string
+	// Clock tells the time, the system clock if nil.
+	Clock Clock
+	// OnError, if set, receives the errors returned by jobs, and the panics of jobs as a
+	// *concurrency.PanicError. Panics are also reported to the concurrency panic hook.
+	OnError func(name string, err error)
+}
+
+// Job is a function run by a Scheduler. ctx is the context given to Scheduler.Start.
+type Job func(ctx context.Context) error
+
+// Scheduler runs jobs on their schedule. Jobs can be added and removed while it runs.
+//
+// Usage:
+//
+//	s, err := NewScheduler(SchedulerConfig{Zone: "Asia/Kolkata"})
+//	err = s.Add("cleanup", "30 2 * * *", OverlapSkip, cleanup)
+//	err = s.Add("refresh", "@every 5m", OverlapQueue, refresh)
+//	s.Start(ctx)
+//	defer s.Stop(shutdownCtx)
+type Scheduler struct {
+	config   SchedulerConfig
+	clock    Clock
+	location *time.Location
+
+	mutex   sync.Mutex
+	entries map[string]*entry
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stopped bool
+	// changed wakes up the loop when entries are added or removed.
+	changed chan struct{}
+	loop    sync.WaitGroup
+	runs    sync.WaitGroup
+}
+
+// entry is a job registered in a Scheduler.
+type entry struct {
+	name     string
+	schedule Schedule
+	overlap  Overlap
+	job      Job
+	next     time.Time
+	// running is the number of runs in progress, queued the number of runs waiting for
+	// them with OverlapQueue.
+	running int
+	queued  int
+}
+
+// NewScheduler returns a scheduler with no jobs. It fails if the time zone is unknown.
+func NewScheduler(config SchedulerConfig) (*Scheduler, error) {
+	location, err := loadLocation(config.Zone)
+	if err != nil {
+		return nil, err
+	}
+	clock := config.Clock
+	if clock == nil {
+		clock = systemClock{}
+	}
+	return &Scheduler{
+		config:   config,
+		clock:    clock,
+		location: location,
+		entries:  make(map[string]*entry),
+		changed:  make(chan struct{}, 1),
+	}, nil
+}
+
+// Add registers job under name, to run on the schedule given by spec, see ParseCron.
+func (s *Scheduler) Add(name string, spec string, overlap Overlap, job Job) error {
+	schedule, err := ParseCron(spec)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.entries[name]; ok {
+		return fmt.Errorf("%w: %s", ErrJobExists, name)
+	}
+	s.entries[name] = &entry{
+		name:     name,
+		schedule: schedule,
+		overlap:  overlap,
+		job:      job,
+		next:     schedule.Next(s.clock.Now().In(s.location)),
+	}
+	s.notify()
+	return nil
+}
+
+// Remove unregisters the job with the given name. A run in progress is not stopped.
+func (s *Scheduler) Remove(name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if e, ok := s.entries[name]; ok {
+		e.queued = 0
+		delete(s.entries, name)
+		s.notify()
+	}
+}
+
+// Next returns the next run time of the job with the given name. Second return parameter
+// is false if there is no such job.
+func (s *Scheduler) Next(name string) (time.Time, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	e, ok := s.entries[name]
+	if !ok {
+		return time.Time{}, false
+	}
+	return e.next, true
+}
+
+// Jobs returns the names of the registered jobs, sorted.
+func (s *Scheduler) Jobs() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	names := make([]string, 0, len(s.entries))
+	for name := range s.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Start starts running jobs in the background, until ctx is done or Stop is called.
+// Jobs get ctx. Calling Start on a running scheduler does nothing.
+func (s *Scheduler) Start(ctx context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.stopped {
+		return ErrSchedulerStopped
+	}
+	if s.ctx != nil {
+		return nil
+	}
+	s.ctx = ctx
+	loopCtx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+	s.loop.Add(1)
+	go s.run(loopCtx)
+	return nil
+}
+
+// Stop stops scheduling runs, drops queued ones and waits for the runs in progress to
+// finish. It returns ctx's error if ctx is done before they finished; they are then
+// left running in the background.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mutex.Lock()
+	s.stopped = true
+	if s.cancel != nil {
+		s.cancel()
+	}
+	for _, e := range s.entries {
+		e.queued = 0
+	}
+	s.mutex.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		s.loop.Wait()
+		s.runs.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// notify wakes up the loop, must be called with the lock held.
+func (s *Scheduler) notify() {
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+// run is the loop of the scheduler: it sleeps until the next job is due and starts the
+// due jobs.
+func (s *Scheduler) run(ctx context.Context) {
+	defer s.loop.Done()
+	for {
+		s.mutex.Lock()
+		var next time.Time
+		for _, e := range s.entries {
+			if !e.next.IsZero() && (next.IsZero() || e.next.Before(next)) {
+				next = e.next
+			}
+		}
+		s.mutex.Unlock()
+
+		var wake <-chan time.Time
+		if !next.IsZero() {
+			wake = s.clock.After(next.Sub(s.clock.Now()))
+		}
+		select {
+		case <-wake:
+			s.dispatchDue()
+		case <-s.changed:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// dispatchDue starts the jobs that are due and schedules their next run.
+func (s *Scheduler) dispatchDue() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.stopped {
+		return
+	}
+	now := s.clock.Now().In(s.location)
+	for _, e := range s.entries {
+		if e.next.IsZero() || e.next.After(now) {
+			continue
+		}
+		e.next = e.schedule.Next(now)
+		switch {
+		case e.running == 0 || e.overlap == OverlapAllow:
+		case e.overlap == OverlapQueue:
+			e.queued++
+			continue
+		default:
+			continue
+		}
+		e.running++
+		s.runs.Add(1)
+		go s.execute(e)
+	}
+}
+
+// execute runs e, then the runs queued behind it.
+func (s *Scheduler) execute(e *entry) {
+	defer s.runs.Done()
+	for {
+		s.call(e)
+		s.mutex.Lock()
+		if e.queued > 0 {
+			e.queued--
+			s.mutex.Unlock()
+			continue
+		}
+		e.running--
+		s.mutex.Unlock()
+		return
+	}
+}
+
+// call runs the job of e once, reporting its error or panic.
+func (s *Scheduler) call(e *entry) {
+	defer func() {
+		if r := recover(); r != nil {
+			perr := concurrency.NewPanicError(r)
+			perr.Context = "timer: job " + e.name
+			concurrency.ReportPanic(perr)
+			s.report(e.name, perr)
+		}
+	}()
+	if err := e.job(s.ctx); err != nil {
+		s.report(e.name, err)
+	}
+}
+
+// report passes the error of a job to OnError, if set.
+func (s *Scheduler) report(name string, err error) {
+	if s.config.OnError != nil {
+		s.config.OnError(name, err)
+	}
+}
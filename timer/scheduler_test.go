@@ -0,0 +1,218 @@
+package timer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sanksons/gowraps/concurrency"
+)
+
+// fakeClock is a Clock whose time only moves with Advance.
+type fakeClock struct {
+	mutex   sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward by d, firing the waiters that are due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			waiters = append(waiters, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = waiters
+}
+
+// eventually fails the test if cond does not become true soon.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// tick advances clock to the next run of the job, and waits for the scheduler to
+// schedule the one after.
+func tick(t *testing.T, s *Scheduler, clock *fakeClock, name string) {
+	t.Helper()
+	next, _ := s.Next(name)
+	clock.Advance(next.Sub(clock.Now()))
+	eventually(t, "the job to be scheduled again", func() bool {
+		after, _ := s.Next(name)
+		return after.After(next)
+	})
+}
+
+func TestSchedulerOverlap(t *testing.T) {
+	tests := []struct {
+		name      string
+		overlap   Overlap
+		wantStart int32
+		wantRuns  int32
+	}{
+		{name: "Skip", overlap: OverlapSkip, wantStart: 1, wantRuns: 1},
+		{name: "Queue", overlap: OverlapQueue, wantStart: 1, wantRuns: 3},
+		{name: "Allow", overlap: OverlapAllow, wantStart: 3, wantRuns: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			s, err := NewScheduler(SchedulerConfig{Clock: clock})
+			if err != nil {
+				t.Fatalf("NewScheduler() unexpected error = %v", err)
+			}
+			var started, runs int32
+			release := make(chan struct{})
+			s.Add("job", "@every 1m", tt.overlap, func(ctx context.Context) error {
+				atomic.AddInt32(&started, 1)
+				<-release
+				atomic.AddInt32(&runs, 1)
+				return nil
+			})
+			s.Start(context.Background())
+
+			tick(t, s, clock, "job")
+			eventually(t, "the first run", func() bool { return atomic.LoadInt32(&started) == 1 })
+			tick(t, s, clock, "job")
+			tick(t, s, clock, "job")
+			if tt.overlap == OverlapAllow {
+				eventually(t, "the overlapping runs", func() bool { return atomic.LoadInt32(&started) == 3 })
+			}
+			if got := atomic.LoadInt32(&started); got != tt.wantStart {
+				t.Errorf("Job started %d times while running, want %d", got, tt.wantStart)
+			}
+
+			close(release)
+			eventually(t, "the queued runs", func() bool { return atomic.LoadInt32(&runs) == tt.wantRuns })
+			if err := s.Stop(context.Background()); err != nil {
+				t.Fatalf("Stop() unexpected error = %v", err)
+			}
+			if got := atomic.LoadInt32(&runs); got != tt.wantRuns {
+				t.Errorf("Job ran %d times, want %d", got, tt.wantRuns)
+			}
+		})
+	}
+}
+
+func TestSchedulerErrors(t *testing.T) {
+	concurrency.SetPanicHook(nil)
+	defer concurrency.SetPanicHook(concurrency.DefaultPanicHook)
+	errBoom := errors.New("boom")
+
+	clock := newFakeClock()
+	reported := make(chan error, 2)
+	s, _ := NewScheduler(SchedulerConfig{
+		Zone:    "Asia/Tokyo",
+		Clock:   clock,
+		OnError: func(name string, err error) { reported <- err },
+	})
+	s.Add("failing", "0 9 * * *", OverlapSkip, func(ctx context.Context) error { return errBoom })
+	s.Add("panicking", "0 9 * * *", OverlapSkip, func(ctx context.Context) error { panic("oops") })
+	if err := s.Add("failing", "@hourly", OverlapSkip, nil); !errors.Is(err, ErrJobExists) {
+		t.Errorf("Add() error = %v, want %v", err, ErrJobExists)
+	}
+	if err := s.Add("invalid", "* *", OverlapSkip, nil); err == nil {
+		t.Errorf("Add() expected error but got none")
+	}
+
+	// 9:00 in Tokyo is 0:00 UTC.
+	next, _ := s.Next("failing")
+	if expected := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC); !next.Equal(expected) {
+		t.Errorf("Next() = %v, want %v", next, expected)
+	}
+
+	s.Start(context.Background())
+	clock.Advance(24 * time.Hour)
+	var gotErr, gotPanic bool
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-reported:
+			var perr *concurrency.PanicError
+			gotPanic = gotPanic || errors.As(err, &perr)
+			gotErr = gotErr || errors.Is(err, errBoom)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for job errors")
+		}
+	}
+	if !gotErr || !gotPanic {
+		t.Errorf("OnError got error %v, panic %v, want both", gotErr, gotPanic)
+	}
+	s.Stop(context.Background())
+
+	if _, err := NewScheduler(SchedulerConfig{Zone: "Invalid/Timezone"}); err == nil {
+		t.Errorf("NewScheduler() expected error but got none")
+	}
+	if err := s.Start(context.Background()); err != ErrSchedulerStopped {
+		t.Errorf("Start() error = %v, want %v", err, ErrSchedulerStopped)
+	}
+}
+
+func TestSchedulerRemove(t *testing.T) {
+	clock := newFakeClock()
+	s, _ := NewScheduler(SchedulerConfig{Clock: clock})
+	var runs int32
+	job := func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}
+	s.Add("a", "@every 1m", OverlapSkip, job)
+	s.Add("b", "@every 1m", OverlapSkip, job)
+	s.Start(context.Background())
+	defer s.Stop(context.Background())
+
+	tick(t, s, clock, "a")
+	eventually(t, "both jobs to run", func() bool { return atomic.LoadInt32(&runs) == 2 })
+	s.Remove("b")
+	if jobs := s.Jobs(); len(jobs) != 1 || jobs[0] != "a" {
+		t.Errorf("Jobs() = %v, want [a]", jobs)
+	}
+	tick(t, s, clock, "a")
+	eventually(t, "the remaining job to run", func() bool { return atomic.LoadInt32(&runs) == 3 })
+	time.Sleep(10 * time.Millisecond)
+	if got := atomic.LoadInt32(&runs); got != 3 {
+		t.Errorf("Jobs ran %d times, want 3 after removing one", got)
+	}
+}
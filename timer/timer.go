@@ -7,13 +7,18 @@ func GetHttpTime(t time.Time) string {
 }
 
 func GetCurrentTime(zone string) (time.Time, error) {
-	if zone == "" {
-		zone = "UTC"
-	}
 	cTime := time.Now()
-	location, locErr := time.LoadLocation(zone)
+	location, locErr := loadLocation(zone)
 	if locErr != nil {
 		return cTime, locErr
 	}
 	return cTime.In(location), nil
 }
+
+// loadLocation returns the location of the named time zone, UTC if zone is empty.
+func loadLocation(zone string) (*time.Location, error) {
+	if zone == "" {
+		zone = "UTC"
+	}
+	return time.LoadLocation(zone)
+}
@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/go-errors/errors"
+	"github.com/sanksons/gowraps/concurrency"
 )
 
 func GetMultiInsertQuery(table string, fields []string, values [][]interface{}) string {
@@ -76,10 +76,12 @@ func OuterJoinInt(set1 []int, set2 []int) (s1 []int, s2 []int) {
 }
 
 //panic
+// PanicHandler recovers a panic and reports it, with msg as context, to the hook set
+// with concurrency.SetPanicHook. Use it as: defer util.PanicHandler("while doing x")
 func PanicHandler(msg string) {
 	if r := recover(); r != nil {
-		fmt.Println("Attention!!!   Panic Occurred !!!")
-		fmt.Println(msg)
-		fmt.Println(errors.Wrap(r, 2).ErrorStack())
+		perr := concurrency.NewPanicError(r)
+		perr.Context = msg
+		concurrency.ReportPanic(perr)
 	}
 }